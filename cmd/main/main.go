@@ -8,9 +8,12 @@ import (
 	"syscall"
 
 	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/queue"
+	"github.com/gkettani/bobber-the-swe/internal/services/deadletter"
 	"github.com/gkettani/bobber-the-swe/internal/services/deduplication"
 	"github.com/gkettani/bobber-the-swe/internal/services/discovery"
 	"github.com/gkettani/bobber-the-swe/internal/services/enrichment"
+	"github.com/gkettani/bobber-the-swe/internal/services/expiry"
 	"github.com/gkettani/bobber-the-swe/internal/services/orchestration"
 	"github.com/gkettani/bobber-the-swe/internal/services/persistence"
 	"github.com/gkettani/bobber-the-swe/internal/services/web"
@@ -37,10 +40,19 @@ func main() {
 	}
 
 	persistenceService := persistence.NewJobPersistenceService(100)
-	deduplicationService := deduplication.NewDeduplicationService()
+	deduplicationService := deduplication.NewDeduplicationService(deduplication.LoadConfig())
+	expiryService := expiry.NewJobExpiryService(expiry.LoadConfig())
+	deadLetterService := deadletter.NewDeadLetterService(deadletter.LoadConfig())
+
+	jobQueue, err := queue.New(queue.LoadConfig())
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to create job reference queue: %v", err))
+		panic(err)
+	}
+	defer jobQueue.Close()
 
 	// Create orchestrator configuration
-	config := orchestration.DefaultConfig()
+	config := orchestration.LoadConfig()
 
 	// Create and start orchestrator
 	orchestrator := orchestration.NewOrchestrator(
@@ -49,10 +61,13 @@ func main() {
 		enrichmentService,
 		persistenceService,
 		deduplicationService,
+		expiryService,
+		deadLetterService,
+		jobQueue,
 	)
 
 	// Create web service
-	webService := web.NewWebService(orchestrator)
+	webService := web.NewWebService(orchestrator, deadLetterService)
 
 	// Start the pipeline
 	if err := orchestrator.Start(ctx); err != nil {
@@ -1,14 +1,31 @@
 CREATE TABLE jobs (
     id SERIAL PRIMARY KEY,
-    external_id TEXT UNIQUE NOT NULL,
+    external_id TEXT NOT NULL,
     company_name TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    company_key TEXT NOT NULL DEFAULT '',
     url TEXT NOT NULL,
     title TEXT NOT NULL,
     location TEXT NOT NULL,
     description TEXT NOT NULL,
+    department TEXT NOT NULL DEFAULT '',
+    offices TEXT NOT NULL DEFAULT '',
+    posting_updated_at TIMESTAMP NULL,
+    team TEXT NOT NULL DEFAULT '',
+    employment_type TEXT NOT NULL DEFAULT '',
+    workplace_type TEXT NOT NULL DEFAULT '',
+    date_posted TIMESTAMP NULL,
+    valid_through TIMESTAMP NULL,
+    salary_min NUMERIC NULL,
+    salary_max NUMERIC NULL,
+    salary_currency TEXT NOT NULL DEFAULT '',
+    salary_unit TEXT NOT NULL DEFAULT '',
+    hiring_organization TEXT NOT NULL DEFAULT '',
     hash TEXT,
     first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    missed_discoveries INTEGER NOT NULL DEFAULT 0,
+    last_scraped_at TIMESTAMP NULL DEFAULT CURRENT_TIMESTAMP,
     expired_at TIMESTAMP NULL,
     search_vector TSVECTOR GENERATED ALWAYS AS (
         setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
@@ -37,8 +54,11 @@ CREATE INDEX CONCURRENTLY jobs_last_seen_idx ON jobs (last_seen_at DESC) WHERE e
 CREATE INDEX CONCURRENTLY jobs_first_seen_idx ON jobs (first_seen_at DESC) WHERE expired_at IS NULL;
 CREATE INDEX CONCURRENTLY jobs_company_last_seen_idx ON jobs (company_name, last_seen_at DESC) WHERE expired_at IS NULL;
 
--- Unique constraint index (already exists but optimized)
-CREATE UNIQUE INDEX CONCURRENTLY jobs_external_id_unique_idx ON jobs (external_id);
+-- Identity of a job, external IDs being only unique within the system of a company
+CREATE UNIQUE INDEX CONCURRENTLY jobs_identity_unique_idx ON jobs (source, company_name, external_id);
+
+-- Active jobs of a company configuration, for expiry detection
+CREATE INDEX CONCURRENTLY jobs_company_key_idx ON jobs (company_key) WHERE expired_at IS NULL;
 
 -- Statistics optimization for text search columns
 ALTER TABLE jobs ALTER COLUMN title SET STATISTICS 200;
@@ -49,6 +69,67 @@ ALTER TABLE jobs ALTER COLUMN company_name SET STATISTICS 100;
 -- Analyze the table to update statistics
 ANALYZE jobs;
 
+-- Previous versions of jobs, stored when the content hash of a job changes
+CREATE TABLE job_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    location TEXT NOT NULL,
+    url TEXT NOT NULL,
+    department TEXT NOT NULL DEFAULT '',
+    offices TEXT NOT NULL DEFAULT '',
+    posting_updated_at TIMESTAMP NULL,
+    team TEXT NOT NULL DEFAULT '',
+    employment_type TEXT NOT NULL DEFAULT '',
+    workplace_type TEXT NOT NULL DEFAULT '',
+    date_posted TIMESTAMP NULL,
+    valid_through TIMESTAMP NULL,
+    salary_min NUMERIC NULL,
+    salary_max NUMERIC NULL,
+    salary_currency TEXT NOT NULL DEFAULT '',
+    salary_unit TEXT NOT NULL DEFAULT '',
+    hiring_organization TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL,
+    valid_from TIMESTAMP NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX job_revisions_job_idx ON job_revisions (job_id, recorded_at DESC);
+
+-- Job references whose processing failed, retried with backoff until they succeed or run out of attempts
+CREATE TABLE dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    source TEXT NOT NULL DEFAULT '',
+    company_name TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    company_key TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    job_ref JSONB NOT NULL,
+    error_class TEXT NOT NULL,
+    last_error TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 1,
+    first_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    next_retry_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX dead_letters_identity_unique_idx ON dead_letters (source, company_name, external_id);
+CREATE INDEX dead_letters_next_retry_idx ON dead_letters (next_retry_at) WHERE next_retry_at IS NOT NULL;
+CREATE INDEX dead_letters_company_key_idx ON dead_letters (company_key);
+
+-- Durable queue of the job references waiting to be enriched (QUEUE_BACKEND=postgres)
+CREATE TABLE job_reference_queue (
+    id BIGSERIAL PRIMARY KEY,
+    company_name TEXT NOT NULL,
+    job_ref JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    enqueued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    visible_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX job_reference_queue_visible_idx ON job_reference_queue (visible_at, id);
+
 -- Create a function for optimized search queries with better memory usage
 CREATE OR REPLACE FUNCTION search_jobs_optimized(
     search_query TEXT,
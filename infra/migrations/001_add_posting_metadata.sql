@@ -0,0 +1,4 @@
+-- Posting metadata returned by ATS APIs (Greenhouse departments, offices, update time)
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS department TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS offices TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS posting_updated_at TIMESTAMP NULL;
@@ -0,0 +1,4 @@
+-- Posting metadata returned by the Lever postings API (team, commitment, workplace type)
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS team TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS employment_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS workplace_type TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Structured fields of schema.org JobPosting (JSON-LD) extracted from career pages
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS date_posted TIMESTAMP NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS valid_through TIMESTAMP NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_min NUMERIC NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_max NUMERIC NULL;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_currency TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_unit TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS hiring_organization TEXT NOT NULL DEFAULT '';
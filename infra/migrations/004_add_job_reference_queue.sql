@@ -0,0 +1,11 @@
+-- Durable queue of the job references waiting to be enriched (QUEUE_BACKEND=postgres)
+CREATE TABLE IF NOT EXISTS job_reference_queue (
+    id BIGSERIAL PRIMARY KEY,
+    company_name TEXT NOT NULL,
+    job_ref JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    enqueued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    visible_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS job_reference_queue_visible_idx ON job_reference_queue (visible_at, id);
@@ -0,0 +1,2 @@
+-- Consecutive discoveries of its company a job was missing from, the job being expired past a threshold
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS missed_discoveries INTEGER NOT NULL DEFAULT 0;
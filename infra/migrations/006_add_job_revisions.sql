@@ -0,0 +1,27 @@
+-- Previous versions of jobs, stored when the content hash of a job changes
+CREATE TABLE IF NOT EXISTS job_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    job_id INTEGER NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    location TEXT NOT NULL,
+    url TEXT NOT NULL,
+    department TEXT NOT NULL DEFAULT '',
+    offices TEXT NOT NULL DEFAULT '',
+    posting_updated_at TIMESTAMP NULL,
+    team TEXT NOT NULL DEFAULT '',
+    employment_type TEXT NOT NULL DEFAULT '',
+    workplace_type TEXT NOT NULL DEFAULT '',
+    date_posted TIMESTAMP NULL,
+    valid_through TIMESTAMP NULL,
+    salary_min NUMERIC NULL,
+    salary_max NUMERIC NULL,
+    salary_currency TEXT NOT NULL DEFAULT '',
+    salary_unit TEXT NOT NULL DEFAULT '',
+    hiring_organization TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL,
+    valid_from TIMESTAMP NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS job_revisions_job_idx ON job_revisions (job_id, recorded_at DESC);
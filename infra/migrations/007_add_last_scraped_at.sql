@@ -0,0 +1,4 @@
+-- Last time a job was scraped, deduplicating job references with DEDUP_BACKEND=postgres. Existing jobs
+-- are left without one, to be enriched again on their next discovery.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_scraped_at TIMESTAMP NULL;
+ALTER TABLE jobs ALTER COLUMN last_scraped_at SET DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,11 @@
+-- Jobs are identified by their source, company and external ID, external IDs being only unique within the
+-- system of a company. Existing jobs keep an empty source until their posting is saved again, which claims
+-- them for the source it was discovered from.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS jobs_identity_unique_idx ON jobs (source, company_name, external_id);
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_external_id_key;
+DROP INDEX CONCURRENTLY IF EXISTS jobs_external_id_unique_idx;
+
+ANALYZE jobs;
@@ -0,0 +1,18 @@
+-- Job references whose processing failed, retried with backoff until they succeed or run out of attempts
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    source TEXT NOT NULL DEFAULT '',
+    company_name TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    job_ref JSONB NOT NULL,
+    error_class TEXT NOT NULL,
+    last_error TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 1,
+    first_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    next_retry_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS dead_letters_identity_unique_idx ON dead_letters (source, company_name, external_id);
+CREATE INDEX IF NOT EXISTS dead_letters_next_retry_idx ON dead_letters (next_retry_at) WHERE next_retry_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Registry key of the company configuration which last listed a job, scoping expiry detection so that
+-- configurations sharing a company name (e.g. one per sitemap) do not expire each other's jobs. Existing
+-- jobs are claimed by the next discovery listing them.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_key TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS jobs_company_key_idx ON jobs (company_key) WHERE expired_at IS NULL;
@@ -0,0 +1,13 @@
+-- Registry key of the company configuration of a dead letter, which the admin endpoints act on so that
+-- configurations sharing a company name are handled apart. Existing dead letters take the key of their job
+-- when it was saved, the others are still retried but out of reach of the admin endpoints.
+ALTER TABLE dead_letters ADD COLUMN IF NOT EXISTS company_key TEXT NOT NULL DEFAULT '';
+
+UPDATE dead_letters
+SET company_key = jobs.company_key
+FROM jobs
+WHERE dead_letters.company_key = '' AND jobs.company_key <> ''
+	AND jobs.source = dead_letters.source AND jobs.company_name = dead_letters.company_name
+	AND jobs.external_id = dead_letters.external_id;
+
+CREATE INDEX IF NOT EXISTS dead_letters_company_key_idx ON dead_letters (company_key);
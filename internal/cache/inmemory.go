@@ -1,38 +1,67 @@
 package cache
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 type InMemoryCache struct {
-	cache map[string]string
+	cache map[string]entry
 	mutex sync.RWMutex
+
+	// Entries expire after ttl when it is set, expired entries being swept at most once per ttl
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+type entry struct {
+	value   string
+	expires time.Time // zero when the entry never expires
 }
 
 func NewInMemoryCache() *InMemoryCache {
 	return &InMemoryCache{
-		cache: make(map[string]string),
+		cache: make(map[string]entry),
 	}
 }
 
+// WithTTL makes the entries set from now on expire after ttl
+func (c *InMemoryCache) WithTTL(ttl time.Duration) *InMemoryCache {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ttl = ttl
+	return c
+}
+
 func (c *InMemoryCache) Get(key string) (string, bool) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	value, exists := c.cache[key]
-	return value, exists
+	e, exists := c.cache[key]
+	if !exists || e.expired(time.Now()) {
+		return "", false
+	}
+	return e.value, true
 }
 
 func (c *InMemoryCache) Set(key, value string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.cache[key] = value
+	e := entry{value: value}
+	if c.ttl > 0 {
+		now := time.Now()
+		e.expires = now.Add(c.ttl)
+		if now.Sub(c.lastSweep) >= c.ttl {
+			c.sweep(now)
+		}
+	}
+	c.cache[key] = e
 }
 
 func (c *InMemoryCache) Exists(key string) bool {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-
-	_, exists := c.cache[key]
+	_, exists := c.Get(key)
 	return exists
 }
 
@@ -42,3 +71,17 @@ func (c *InMemoryCache) Delete(key string) {
 
 	delete(c.cache, key)
 }
+
+// sweep removes the expired entries, the cache being locked
+func (c *InMemoryCache) sweep(now time.Time) {
+	for key, e := range c.cache {
+		if e.expired(now) {
+			delete(c.cache, key)
+		}
+	}
+	c.lastSweep = now
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
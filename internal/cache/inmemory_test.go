@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInMemoryCache_TTL(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		wait       time.Duration
+		wantExists bool
+	}{
+		{name: "no ttl", wait: 20 * time.Millisecond, wantExists: true},
+		{name: "within ttl", ttl: time.Hour, wait: 0, wantExists: true},
+		{name: "past ttl", ttl: 10 * time.Millisecond, wait: 20 * time.Millisecond, wantExists: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewInMemoryCache().WithTTL(tt.ttl)
+			c.Set("job", "1")
+			time.Sleep(tt.wait)
+
+			if got := c.Exists("job"); got != tt.wantExists {
+				t.Errorf("Exists() = %v, want %v", got, tt.wantExists)
+			}
+			if _, got := c.Get("job"); got != tt.wantExists {
+				t.Errorf("Get() found = %v, want %v", got, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestInMemoryCache_SweepsExpiredEntries(t *testing.T) {
+	c := NewInMemoryCache().WithTTL(10 * time.Millisecond)
+	c.Set("old", "1")
+	time.Sleep(20 * time.Millisecond)
+	c.Set("new", "2")
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if _, exists := c.cache["old"]; exists {
+		t.Errorf("Expected expired entry to be swept")
+	}
+	if _, exists := c.cache["new"]; !exists {
+		t.Errorf("Expected new entry to be kept")
+	}
+}
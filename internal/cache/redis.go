@@ -39,6 +39,12 @@ func NewRedisCache() (*RedisCache, error) {
 	}, nil
 }
 
+// WithTTL makes the entries set from now on expire after ttl instead of 24 hours
+func (c *RedisCache) WithTTL(ttl time.Duration) *RedisCache {
+	c.defaultTTL = ttl
+	return c
+}
+
 func getRedisConfig() *RedisConfig {
 	c := RedisConfig{}
 	if err := env.Parse(&c); err != nil {
@@ -0,0 +1,175 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// ATSAdapter discovers and enriches job postings through the public API of an
+// applicant tracking system, so that a company only needs its board slug to be tracked.
+type ATSAdapter interface {
+	// Discover lists the open postings of the company's board
+	Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error)
+
+	// Enrich fetches the full details of a posting returned by Discover
+	Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error)
+}
+
+// hostedURLMatcher is implemented by adapters able to enrich postings discovered by
+// other fetch types, recognizing them by their hosted URL
+type hostedURLMatcher interface {
+	MatchesHostedURL(hostedURL string) bool
+}
+
+// enrichmentURLResolver is implemented by adapters enriching postings from another URL than their APIURL,
+// such as the ones recognizing hosted URLs
+type enrichmentURLResolver interface {
+	EnrichmentURL(jobReference *models.JobReference) string
+}
+
+var (
+	atsAdapters   = make(map[string]ATSAdapter)
+	atsAdaptersMu sync.RWMutex
+)
+
+// RegisterATSAdapter makes an adapter available as a fetch type
+func RegisterATSAdapter(fetchType string, adapter ATSAdapter) {
+	atsAdaptersMu.Lock()
+	defer atsAdaptersMu.Unlock()
+	atsAdapters[fetchType] = adapter
+}
+
+// GetATSAdapter returns the adapter registered for a fetch type
+func GetATSAdapter(fetchType string) (ATSAdapter, bool) {
+	atsAdaptersMu.RLock()
+	defer atsAdaptersMu.RUnlock()
+	adapter, exists := atsAdapters[fetchType]
+	return adapter, exists
+}
+
+// ATSAdapterForReference returns the adapter able to enrich a job reference, either the one
+// that discovered it or one recognizing its hosted URL
+func ATSAdapterForReference(jobReference *models.JobReference) (ATSAdapter, bool) {
+	if adapter, exists := GetATSAdapter(jobReference.Source); exists {
+		return adapter, true
+	}
+
+	atsAdaptersMu.RLock()
+	defer atsAdaptersMu.RUnlock()
+	for _, adapter := range atsAdapters {
+		if matcher, ok := adapter.(hostedURLMatcher); ok && matcher.MatchesHostedURL(jobReference.URL) {
+			return adapter, true
+		}
+	}
+
+	return nil, false
+}
+
+// EnrichmentURL returns the URL a job reference is enriched from: the API requested by the adapter enriching
+// it, or its page when it is scraped
+func EnrichmentURL(jobReference *models.JobReference) string {
+	if adapter, exists := ATSAdapterForReference(jobReference); exists {
+		if resolver, ok := adapter.(enrichmentURLResolver); ok {
+			if enrichmentURL := resolver.EnrichmentURL(jobReference); enrichmentURL != "" {
+				return enrichmentURL
+			}
+		}
+	}
+	return firstNonEmpty(jobReference.APIURL, jobReference.URL)
+}
+
+// atsEndpoint returns the company URL when set, so that any board endpoint can be overridden
+// (regional hosting, recorded fixtures), or the adapter's default endpoint for the board
+func atsEndpoint(config CompanyConfig, format string) string {
+	if config.URL != "" {
+		return config.URL
+	}
+	return fmt.Sprintf(format, config.Board)
+}
+
+// getJSON fetches an ATS endpoint and decodes its JSON response into v
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	return doJSON(ctx, client, "GET", url, nil, v)
+}
+
+// doJSON sends a request to an ATS endpoint, with an optional JSON body, and decodes its JSON response into v
+func doJSON(ctx context.Context, client *http.Client, method, url string, body any, v any) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse API response: %w", err)
+	}
+
+	return nil
+}
+
+// joinNonEmpty joins the non-empty values with a comma
+func joinNonEmpty(values ...string) string {
+	nonEmpty := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+	return strings.Join(nonEmpty, ", ")
+}
+
+// appendPathSegment builds the URL of a single posting from a board endpoint
+func appendPathSegment(endpoint, segment string, keepQuery bool) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	if !keepQuery {
+		u.RawQuery = ""
+	}
+	u.Path = fmt.Sprintf("%s/%s", strings.TrimSuffix(u.Path, "/"), url.PathEscape(segment))
+	return u.String()
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
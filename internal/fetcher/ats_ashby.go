@@ -0,0 +1,139 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("ashby", &ashbyAdapter{})
+}
+
+// ashbyAdapter reads postings from the Ashby job posting API
+type ashbyAdapter struct{}
+
+type ashbyJobBoard struct {
+	Jobs []ashbyJob `json:"jobs"`
+}
+
+type ashbyJob struct {
+	ID                 string `json:"id"`
+	Title              string `json:"title"`
+	Location           string `json:"location"`
+	Department         string `json:"department"`
+	Team               string `json:"team"`
+	EmploymentType     string `json:"employmentType"`
+	WorkplaceType      string `json:"workplaceType"`
+	IsRemote           bool   `json:"isRemote"`
+	DescriptionHTML    string `json:"descriptionHtml"`
+	PublishedAt        string `json:"publishedAt"`
+	JobURL             string `json:"jobUrl"`
+	SecondaryLocations []struct {
+		Location string `json:"location"`
+	} `json:"secondaryLocations"`
+}
+
+// Discover lists the postings of the job board with their full details, the board holding everything
+// enrichment would otherwise download it again for
+func (a *ashbyAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint := atsEndpoint(config, "https://api.ashbyhq.com/posting-api/job-board/%s")
+
+	var board ashbyJobBoard
+	if err := getJSON(ctx, client, endpoint, &board); err != nil {
+		return nil, fmt.Errorf("failed to fetch ashby job board: %w", err)
+	}
+
+	var jobs []*models.JobReference
+	for _, job := range board.Jobs {
+		if job.ID == "" || job.JobURL == "" {
+			continue
+		}
+
+		jobReference := &models.JobReference{
+			ExternalID: job.ID,
+			URL:        job.JobURL,
+			Source:     "ashby",
+			APIURL:     endpoint,
+		}
+		if details := job.details(); details.Title != "" {
+			jobReference.Title = details.Title
+			jobReference.Location = details.Location
+			jobReference.Description = details.Description
+			jobReference.Details = details
+		}
+		jobs = append(jobs, jobReference)
+	}
+
+	return jobs, nil
+}
+
+// Enrich looks the posting up in its job board, as Ashby has no public endpoint for a single posting. It is
+// only needed for references discovered without their details, the board being requested in full as
+// it is shared by every posting of the company.
+func (a *ashbyAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	if jobReference.APIURL == "" {
+		return nil, fmt.Errorf("no API URL for ashby job %s", jobReference.ExternalID)
+	}
+
+	var board ashbyJobBoard
+	if err := getJSON(httpclient.Unconditional(ctx), client, jobReference.APIURL, &board); err != nil {
+		return nil, err
+	}
+
+	for _, job := range board.Jobs {
+		if job.ID != jobReference.ExternalID {
+			continue
+		}
+
+		details := job.details()
+		if details.Title == "" {
+			return nil, fmt.Errorf("could not extract job title from ashby job %s", jobReference.ExternalID)
+		}
+		details.ExternalID = jobReference.ExternalID
+		details.CompanyName = jobReference.CompanyName
+		details.URL = firstNonEmpty(jobReference.URL, job.JobURL)
+
+		return details, nil
+	}
+
+	// The posting was taken down since it was discovered
+	return nil, fmt.Errorf("ashby job %s is no longer on the job board: %w", jobReference.ExternalID, httpclient.ErrNotFound)
+}
+
+// details returns the details of a posting listed by the job board
+func (job *ashbyJob) details() *models.JobDetails {
+	offices := []string{job.Location}
+	for _, secondary := range job.SecondaryLocations {
+		offices = append(offices, secondary.Location)
+	}
+
+	workplaceType := job.WorkplaceType
+	if workplaceType == "" && job.IsRemote {
+		workplaceType = "Remote"
+	}
+
+	details := &models.JobDetails{
+		ExternalID:     job.ID,
+		URL:            job.JobURL,
+		Title:          strings.TrimSpace(job.Title),
+		Location:       strings.TrimSpace(job.Location),
+		Description:    strings.TrimSpace(job.DescriptionHTML),
+		Department:     job.Department,
+		Offices:        joinNonEmpty(offices...),
+		Team:           job.Team,
+		EmploymentType: job.EmploymentType,
+		WorkplaceType:  workplaceType,
+	}
+
+	if publishedAt, err := time.Parse(time.RFC3339, job.PublishedAt); err == nil {
+		details.DatePosted = &publishedAt
+	}
+
+	return details
+}
@@ -0,0 +1,109 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("greenhouse", &greenhouseAdapter{})
+}
+
+// greenhouseAdapter reads postings from the Greenhouse Job Board API
+type greenhouseAdapter struct{}
+
+type greenhouseJob struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	AbsoluteURL string `json:"absolute_url"`
+	UpdatedAt   string `json:"updated_at"`
+	Content     string `json:"content"`
+	Location    struct {
+		Name string `json:"name"`
+	} `json:"location"`
+	Departments []struct {
+		Name string `json:"name"`
+	} `json:"departments"`
+	Offices []struct {
+		Name string `json:"name"`
+	} `json:"offices"`
+}
+
+func (a *greenhouseAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint := atsEndpoint(config, "https://boards-api.greenhouse.io/v1/boards/%s/jobs")
+
+	var board struct {
+		Jobs []greenhouseJob `json:"jobs"`
+	}
+	if err := getJSON(ctx, client, endpoint, &board); err != nil {
+		return nil, fmt.Errorf("failed to fetch greenhouse board: %w", err)
+	}
+
+	var jobs []*models.JobReference
+	for _, job := range board.Jobs {
+		if job.ID == 0 || job.AbsoluteURL == "" {
+			continue
+		}
+
+		externalID := strconv.FormatInt(job.ID, 10)
+		jobs = append(jobs, &models.JobReference{
+			ExternalID: externalID,
+			URL:        job.AbsoluteURL,
+			Source:     "greenhouse",
+			APIURL:     appendPathSegment(endpoint, externalID, false),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (a *greenhouseAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	if jobReference.APIURL == "" {
+		return nil, fmt.Errorf("no API URL for greenhouse job %s", jobReference.ExternalID)
+	}
+
+	var job greenhouseJob
+	if err := getJSON(ctx, client, jobReference.APIURL, &job); err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(job.Title)
+	if title == "" {
+		return nil, fmt.Errorf("could not extract job title from greenhouse job %s", jobReference.ExternalID)
+	}
+
+	departments := make([]string, 0, len(job.Departments))
+	for _, department := range job.Departments {
+		departments = append(departments, department.Name)
+	}
+
+	offices := make([]string, 0, len(job.Offices))
+	for _, office := range job.Offices {
+		offices = append(offices, office.Name)
+	}
+
+	details := &models.JobDetails{
+		ExternalID:  jobReference.ExternalID,
+		CompanyName: jobReference.CompanyName,
+		URL:         firstNonEmpty(jobReference.URL, job.AbsoluteURL),
+		Title:       title,
+		Location:    strings.TrimSpace(job.Location.Name),
+		// Greenhouse returns the description HTML-escaped
+		Description: strings.TrimSpace(html.UnescapeString(job.Content)),
+		Department:  joinNonEmpty(departments...),
+		Offices:     joinNonEmpty(offices...),
+	}
+
+	if updatedAt, err := time.Parse(time.RFC3339, job.UpdatedAt); err == nil {
+		details.PostingUpdatedAt = &updatedAt
+	}
+
+	return details, nil
+}
@@ -0,0 +1,133 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("lever", &leverAdapter{})
+}
+
+// leverAdapter reads postings from the Lever postings API
+type leverAdapter struct{}
+
+type leverPosting struct {
+	ID         string `json:"id"`
+	Text       string `json:"text"`
+	HostedURL  string `json:"hostedUrl"`
+	Categories struct {
+		Commitment string `json:"commitment"`
+		Department string `json:"department"`
+		Location   string `json:"location"`
+		Team       string `json:"team"`
+	} `json:"categories"`
+	Description string `json:"description"`
+	Lists       []struct {
+		Text    string `json:"text"`
+		Content string `json:"content"`
+	} `json:"lists"`
+	Additional    string `json:"additional"`
+	WorkplaceType string `json:"workplaceType"`
+}
+
+func (a *leverAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint := atsEndpoint(config, "https://api.lever.co/v0/postings/%s?mode=json")
+
+	var postings []leverPosting
+	if err := getJSON(ctx, client, endpoint, &postings); err != nil {
+		return nil, fmt.Errorf("failed to fetch lever postings: %w", err)
+	}
+
+	var jobs []*models.JobReference
+	for _, posting := range postings {
+		if posting.ID == "" || posting.HostedURL == "" {
+			continue
+		}
+
+		jobs = append(jobs, &models.JobReference{
+			ExternalID: posting.ID,
+			URL:        posting.HostedURL,
+			Source:     "lever",
+			APIURL:     appendPathSegment(endpoint, posting.ID, true),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (a *leverAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	apiURL := leverAPIURL(jobReference)
+	if apiURL == "" {
+		return nil, fmt.Errorf("no API URL for lever job %s", jobReference.ExternalID)
+	}
+
+	var posting leverPosting
+	if err := getJSON(ctx, client, apiURL, &posting); err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(posting.Text)
+	if title == "" {
+		return nil, fmt.Errorf("could not extract job title from lever posting %s", jobReference.ExternalID)
+	}
+
+	// The description is split into an introduction, titled lists and a closing section
+	var description strings.Builder
+	description.WriteString(posting.Description)
+	for _, list := range posting.Lists {
+		fmt.Fprintf(&description, "<h3>%s</h3><ul>%s</ul>", list.Text, list.Content)
+	}
+	description.WriteString(posting.Additional)
+
+	return &models.JobDetails{
+		ExternalID:     jobReference.ExternalID,
+		CompanyName:    jobReference.CompanyName,
+		URL:            firstNonEmpty(jobReference.URL, posting.HostedURL),
+		Title:          title,
+		Location:       strings.TrimSpace(posting.Categories.Location),
+		Description:    strings.TrimSpace(description.String()),
+		Department:     posting.Categories.Department,
+		Team:           posting.Categories.Team,
+		EmploymentType: posting.Categories.Commitment,
+		WorkplaceType:  posting.WorkplaceType,
+	}, nil
+}
+
+// MatchesHostedURL lets postings on jobs.lever.co be enriched through the API whatever discovered them
+func (a *leverAdapter) MatchesHostedURL(hostedURL string) bool {
+	return leverAPIURL(&models.JobReference{URL: hostedURL}) != ""
+}
+
+// EnrichmentURL is the postings API URL of a reference, whether it was discovered through the API or by its
+// hosted URL
+func (a *leverAdapter) EnrichmentURL(jobReference *models.JobReference) string {
+	return leverAPIURL(jobReference)
+}
+
+// leverAPIURL returns the postings API URL of a Lever job reference, derived from its
+// hosted URL (jobs.lever.co/{site}/{id}) when discovery did not provide one.
+// It returns an empty string for references that are not hosted on Lever.
+func leverAPIURL(jobReference *models.JobReference) string {
+	if jobReference.Source == "lever" && jobReference.APIURL != "" {
+		return jobReference.APIURL
+	}
+
+	u, err := url.Parse(jobReference.URL)
+	if err != nil || !strings.HasPrefix(u.Host, "jobs.") || !strings.HasSuffix(u.Host, "lever.co") {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	apiHost := "api." + strings.TrimPrefix(u.Host, "jobs.")
+	return fmt.Sprintf("https://%s/v0/postings/%s/%s", apiHost, parts[0], parts[1])
+}
@@ -0,0 +1,119 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("recruitee", &recruiteeAdapter{})
+}
+
+// recruiteeAdapter reads offers from the Recruitee careers site API
+type recruiteeAdapter struct{}
+
+type recruiteeOffer struct {
+	ID                 int64  `json:"id"`
+	Slug               string `json:"slug"`
+	Title              string `json:"title"`
+	CareersURL         string `json:"careers_url"`
+	Location           string `json:"location"`
+	Department         string `json:"department"`
+	EmploymentTypeCode string `json:"employment_type_code"`
+	Remote             bool   `json:"remote"`
+	Hybrid             bool   `json:"hybrid"`
+	OnSite             bool   `json:"on_site"`
+	Description        string `json:"description"`
+	Requirements       string `json:"requirements"`
+	UpdatedAt          string `json:"updated_at"`
+	Locations          []struct {
+		Name string `json:"name"`
+	} `json:"locations"`
+}
+
+func (a *recruiteeAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint := atsEndpoint(config, "https://%s.recruitee.com/api/offers/")
+
+	var site struct {
+		Offers []recruiteeOffer `json:"offers"`
+	}
+	if err := getJSON(ctx, client, endpoint, &site); err != nil {
+		return nil, fmt.Errorf("failed to fetch recruitee offers: %w", err)
+	}
+
+	var jobs []*models.JobReference
+	for _, offer := range site.Offers {
+		if offer.ID == 0 || offer.CareersURL == "" {
+			continue
+		}
+
+		jobs = append(jobs, &models.JobReference{
+			ExternalID: strconv.FormatInt(offer.ID, 10),
+			URL:        offer.CareersURL,
+			Source:     "recruitee",
+			APIURL:     appendPathSegment(endpoint, offer.Slug, false),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (a *recruiteeAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	if jobReference.APIURL == "" {
+		return nil, fmt.Errorf("no API URL for recruitee offer %s", jobReference.ExternalID)
+	}
+
+	var response struct {
+		Offer recruiteeOffer `json:"offer"`
+	}
+	if err := getJSON(ctx, client, jobReference.APIURL, &response); err != nil {
+		return nil, err
+	}
+	offer := response.Offer
+
+	title := strings.TrimSpace(offer.Title)
+	if title == "" {
+		return nil, fmt.Errorf("could not extract job title from recruitee offer %s", jobReference.ExternalID)
+	}
+
+	offices := make([]string, 0, len(offer.Locations))
+	for _, location := range offer.Locations {
+		offices = append(offices, location.Name)
+	}
+
+	var workplaceType string
+	switch {
+	case offer.Remote:
+		workplaceType = "remote"
+	case offer.Hybrid:
+		workplaceType = "hybrid"
+	case offer.OnSite:
+		workplaceType = "on-site"
+	}
+
+	details := &models.JobDetails{
+		ExternalID:     jobReference.ExternalID,
+		CompanyName:    jobReference.CompanyName,
+		URL:            firstNonEmpty(jobReference.URL, offer.CareersURL),
+		Title:          title,
+		Location:       strings.TrimSpace(offer.Location),
+		Description:    strings.TrimSpace(offer.Description + offer.Requirements),
+		Department:     offer.Department,
+		Offices:        joinNonEmpty(offices...),
+		EmploymentType: offer.EmploymentTypeCode,
+		WorkplaceType:  workplaceType,
+	}
+
+	// Recruitee timestamps look like "2024-03-01 09:30:00 UTC"
+	if updatedAt, err := time.Parse("2006-01-02 15:04:05 MST", offer.UpdatedAt); err == nil {
+		details.PostingUpdatedAt = &updatedAt
+	}
+
+	return details, nil
+}
@@ -0,0 +1,177 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("smartrecruiters", &smartRecruitersAdapter{})
+}
+
+// smartRecruitersPageSize is the largest page the SmartRecruiters posting API serves
+const smartRecruitersPageSize = 100
+
+// smartRecruitersAdapter reads postings from the SmartRecruiters posting API
+type smartRecruitersAdapter struct{}
+
+type smartRecruitersLabel struct {
+	Label string `json:"label"`
+}
+
+type smartRecruitersLocation struct {
+	City         string `json:"city"`
+	Region       string `json:"region"`
+	Country      string `json:"country"`
+	Remote       bool   `json:"remote"`
+	FullLocation string `json:"fullLocation"`
+}
+
+type smartRecruitersSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type smartRecruitersPosting struct {
+	ID               string                  `json:"id"`
+	Name             string                  `json:"name"`
+	Ref              string                  `json:"ref"`
+	PostingURL       string                  `json:"postingUrl"`
+	ReleasedDate     string                  `json:"releasedDate"`
+	Location         smartRecruitersLocation `json:"location"`
+	Department       smartRecruitersLabel    `json:"department"`
+	Function         smartRecruitersLabel    `json:"function"`
+	TypeOfEmployment smartRecruitersLabel    `json:"typeOfEmployment"`
+	JobAd            struct {
+		Sections struct {
+			CompanyDescription    smartRecruitersSection `json:"companyDescription"`
+			JobDescription        smartRecruitersSection `json:"jobDescription"`
+			Qualifications        smartRecruitersSection `json:"qualifications"`
+			AdditionalInformation smartRecruitersSection `json:"additionalInformation"`
+		} `json:"sections"`
+	} `json:"jobAd"`
+}
+
+func (a *smartRecruitersAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint := atsEndpoint(config, "https://api.smartrecruiters.com/v1/companies/%s/postings")
+
+	var jobs []*models.JobReference
+	for offset := 0; ; offset += smartRecruitersPageSize {
+		pageURL, err := withQueryParams(endpoint, map[string]string{
+			"limit":  strconv.Itoa(smartRecruitersPageSize),
+			"offset": strconv.Itoa(offset),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid smartrecruiters endpoint: %w", err)
+		}
+
+		var page struct {
+			TotalFound int                      `json:"totalFound"`
+			Content    []smartRecruitersPosting `json:"content"`
+		}
+		if err := getJSON(ctx, client, pageURL, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch smartrecruiters postings: %w", err)
+		}
+
+		for _, posting := range page.Content {
+			if posting.ID == "" {
+				continue
+			}
+
+			jobs = append(jobs, &models.JobReference{
+				ExternalID: posting.ID,
+				URL:        firstNonEmpty(posting.PostingURL, fmt.Sprintf("https://jobs.smartrecruiters.com/%s/%s", config.Board, posting.ID)),
+				Source:     "smartrecruiters",
+				APIURL:     firstNonEmpty(posting.Ref, appendPathSegment(endpoint, posting.ID, false)),
+			})
+		}
+
+		if len(page.Content) == 0 || offset+len(page.Content) >= page.TotalFound {
+			break
+		}
+		// Only the first page tells whether the listing changed
+		ctx = httpclient.Unconditional(ctx)
+	}
+
+	return jobs, nil
+}
+
+func (a *smartRecruitersAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	if jobReference.APIURL == "" {
+		return nil, fmt.Errorf("no API URL for smartrecruiters job %s", jobReference.ExternalID)
+	}
+
+	var posting smartRecruitersPosting
+	if err := getJSON(ctx, client, jobReference.APIURL, &posting); err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(posting.Name)
+	if title == "" {
+		return nil, fmt.Errorf("could not extract job title from smartrecruiters posting %s", jobReference.ExternalID)
+	}
+
+	var description strings.Builder
+	sections := posting.JobAd.Sections
+	for _, section := range []smartRecruitersSection{sections.CompanyDescription, sections.JobDescription, sections.Qualifications, sections.AdditionalInformation} {
+		if section.Text == "" {
+			continue
+		}
+		if section.Title != "" {
+			fmt.Fprintf(&description, "<h3>%s</h3>", section.Title)
+		}
+		description.WriteString(section.Text)
+	}
+
+	location := posting.Location.FullLocation
+	if location == "" {
+		location = joinNonEmpty(posting.Location.City, posting.Location.Region, posting.Location.Country)
+	}
+
+	var workplaceType string
+	if posting.Location.Remote {
+		workplaceType = "remote"
+	}
+
+	details := &models.JobDetails{
+		ExternalID:     jobReference.ExternalID,
+		CompanyName:    jobReference.CompanyName,
+		URL:            firstNonEmpty(posting.PostingURL, jobReference.URL),
+		Title:          title,
+		Location:       location,
+		Description:    strings.TrimSpace(description.String()),
+		Department:     firstNonEmpty(posting.Department.Label, posting.Function.Label),
+		EmploymentType: posting.TypeOfEmployment.Label,
+		WorkplaceType:  workplaceType,
+	}
+
+	if releasedDate, err := time.Parse(time.RFC3339, posting.ReleasedDate); err == nil {
+		details.DatePosted = &releasedDate
+	}
+
+	return details, nil
+}
+
+// withQueryParams sets query parameters on a URL, keeping the ones already present
+func withQueryParams(rawURL string, params map[string]string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,496 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// newFixtureServer serves recorded ATS responses from testdata/ats, keyed by request path.
+// Occurrences of {{server}} in a fixture are replaced with the server's URL.
+func newFixtureServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, exists := fixtures[r.URL.Path]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", "ats", fixture))
+		if err != nil {
+			t.Errorf("Failed to read fixture %s: %v", fixture, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(strings.ReplaceAll(string(data), "{{server}}", server.URL)))
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestATSAdapters(t *testing.T) {
+	tests := []struct {
+		fetchType    string
+		listPath     string
+		fixtures     map[string]string
+		wantID       string
+		wantURL      string
+		wantDetails  models.JobDetails
+		wantInDetail string
+		wantPosted   string
+	}{
+		{
+			fetchType: "greenhouse",
+			listPath:  "/v1/boards/acme/jobs",
+			fixtures: map[string]string{
+				"/v1/boards/acme/jobs":         "greenhouse_board.json",
+				"/v1/boards/acme/jobs/4012345": "greenhouse_job.json",
+			},
+			wantID:  "4012345",
+			wantURL: "https://job-boards.greenhouse.io/acme/jobs/4012345",
+			wantDetails: models.JobDetails{
+				Title:      "Software Engineer, Infrastructure",
+				Location:   "Paris, France",
+				Department: "Engineering, Infrastructure",
+				Offices:    "Paris",
+			},
+			wantInDetail: "<p>Build the platform &amp; tooling.</p>",
+		},
+		{
+			fetchType: "lever",
+			listPath:  "/v0/postings/acme",
+			fixtures: map[string]string{
+				"/v0/postings/acme": "lever_postings.json",
+				"/v0/postings/acme/5ac21346-8e0c-4494-8e7a-3eb92ff77902": "lever_posting.json",
+			},
+			wantID:  "5ac21346-8e0c-4494-8e7a-3eb92ff77902",
+			wantURL: "https://jobs.lever.co/acme/5ac21346-8e0c-4494-8e7a-3eb92ff77902",
+			wantDetails: models.JobDetails{
+				Title:          "Backend Engineer",
+				Location:       "Paris",
+				Department:     "R&D",
+				Team:           "Platform",
+				EmploymentType: "Full-time",
+				WorkplaceType:  "hybrid",
+			},
+			wantInDetail: "<h3>What you will do</h3><ul><li>Design APIs</li>",
+		},
+		{
+			fetchType: "ashby",
+			listPath:  "/posting-api/job-board/acme",
+			fixtures: map[string]string{
+				"/posting-api/job-board/acme": "ashby_job_board.json",
+			},
+			wantID:  "0c81b35a-6e3a-4a0b-a8d2-8e4c7b0fb2a1",
+			wantURL: "https://jobs.ashbyhq.com/acme/0c81b35a-6e3a-4a0b-a8d2-8e4c7b0fb2a1",
+			wantDetails: models.JobDetails{
+				Title:          "Data Engineer",
+				Location:       "London",
+				Department:     "Data",
+				Offices:        "London, Berlin",
+				Team:           "Analytics Platform",
+				EmploymentType: "FullTime",
+				WorkplaceType:  "Hybrid",
+			},
+			wantInDetail: "<p>Own our pipelines.</p>",
+			wantPosted:   "2025-01-15",
+		},
+		{
+			fetchType: "workable",
+			listPath:  "/api/v1/widget/accounts/acme",
+			fixtures: map[string]string{
+				"/api/v1/widget/accounts/acme":          "workable_account.json",
+				"/api/v2/accounts/acme/jobs/A1B2C3D4E5": "workable_job.json",
+			},
+			wantID:  "A1B2C3D4E5",
+			wantURL: "https://apply.workable.com/j/A1B2C3D4E5",
+			wantDetails: models.JobDetails{
+				Title:          "Site Reliability Engineer",
+				Location:       "Lyon, Auvergne-Rhône-Alpes, France",
+				Department:     "Engineering",
+				Offices:        "Lyon, Auvergne-Rhône-Alpes, France",
+				EmploymentType: "full",
+				WorkplaceType:  "hybrid",
+			},
+			wantInDetail: "<ul><li>Kubernetes</li></ul>",
+			wantPosted:   "2025-02-01",
+		},
+		{
+			fetchType: "smartrecruiters",
+			listPath:  "/v1/companies/acme/postings",
+			fixtures: map[string]string{
+				"/v1/companies/acme/postings":                 "smartrecruiters_postings.json",
+				"/v1/companies/acme/postings/744000012345678": "smartrecruiters_posting.json",
+			},
+			wantID:  "744000012345678",
+			wantURL: "https://jobs.smartrecruiters.com/acme/744000012345678",
+			wantDetails: models.JobDetails{
+				Title:          "Machine Learning Engineer",
+				Location:       "Amsterdam, NH, Netherlands",
+				Department:     "AI Research",
+				EmploymentType: "Full-time",
+				WorkplaceType:  "remote",
+			},
+			wantInDetail: "<h3>Job Description</h3><p>Train models.</p>",
+			wantPosted:   "2025-03-01",
+		},
+		{
+			fetchType: "recruitee",
+			listPath:  "/api/offers/",
+			fixtures: map[string]string{
+				"/api/offers/":                   "recruitee_offers.json",
+				"/api/offers/frontend-developer": "recruitee_offer.json",
+			},
+			wantID:  "1701234",
+			wantURL: "https://acme.recruitee.com/o/frontend-developer",
+			wantDetails: models.JobDetails{
+				Title:          "Frontend Developer",
+				Location:       "Utrecht, Netherlands",
+				Department:     "Product",
+				Offices:        "Utrecht, Amsterdam",
+				EmploymentType: "fulltime_permanent",
+				WorkplaceType:  "hybrid",
+			},
+			wantInDetail: "<p>Ship the UI.</p><p>TypeScript.</p>",
+		},
+		{
+			fetchType: "workday",
+			listPath:  "/wday/cxs/acme/External/jobs",
+			fixtures: map[string]string{
+				"/wday/cxs/acme/External/jobs":                                        "workday_jobs.json",
+				"/wday/cxs/acme/External/job/Paris/Site-Reliability-Engineer_JR-1042": "workday_job.json",
+			},
+			wantID:  "JR-1042",
+			wantURL: "{{server}}/External/job/Paris/Site-Reliability-Engineer_JR-1042",
+			wantDetails: models.JobDetails{
+				Title:          "Site Reliability Engineer",
+				Location:       "Paris",
+				Offices:        "Paris, Lyon",
+				EmploymentType: "Full time",
+				WorkplaceType:  "Hybrid",
+			},
+			wantInDetail: "<p>Keep our clusters healthy.</p>",
+			wantPosted:   "2025-03-10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fetchType, func(t *testing.T) {
+			server := newFixtureServer(t, tt.fixtures)
+			ctx := context.Background()
+
+			adapter, exists := GetATSAdapter(tt.fetchType)
+			if !exists {
+				t.Fatalf("Expected an adapter for fetch type %s", tt.fetchType)
+			}
+
+			config := CompanyConfig{
+				Name:      "Acme",
+				FetchType: tt.fetchType,
+				Board:     "acme",
+				URL:       server.URL + tt.listPath,
+			}
+			if err := config.Validate(); err != nil {
+				t.Fatalf("Expected valid config, got: %v", err)
+			}
+
+			jobs, err := adapter.Discover(ctx, server.Client(), config)
+			if err != nil {
+				t.Fatalf("Discover() error = %v", err)
+			}
+
+			if len(jobs) == 0 {
+				t.Fatal("Expected job references, got none")
+			}
+
+			jobRef := jobs[0]
+			wantURL := strings.ReplaceAll(tt.wantURL, "{{server}}", server.URL)
+			if jobRef.ExternalID != tt.wantID || jobRef.URL != wantURL || jobRef.Source != tt.fetchType {
+				t.Fatalf("Unexpected job reference: %+v", jobRef)
+			}
+
+			jobRef.CompanyName = config.Name
+			details, err := adapter.Enrich(ctx, server.Client(), jobRef)
+			if err != nil {
+				t.Fatalf("Enrich() error = %v", err)
+			}
+
+			if details.ExternalID != tt.wantID || details.CompanyName != "Acme" || !details.IsValid() {
+				t.Errorf("Unexpected job identity: %+v", details)
+			}
+
+			got := models.JobDetails{
+				Title:          details.Title,
+				Location:       details.Location,
+				Department:     details.Department,
+				Offices:        details.Offices,
+				Team:           details.Team,
+				EmploymentType: details.EmploymentType,
+				WorkplaceType:  details.WorkplaceType,
+			}
+			if got != tt.wantDetails {
+				t.Errorf("Enrich() = %+v, want %+v", got, tt.wantDetails)
+			}
+
+			if !strings.Contains(details.Description, tt.wantInDetail) {
+				t.Errorf("Expected description to contain %q, got: %q", tt.wantInDetail, details.Description)
+			}
+
+			if tt.wantPosted != "" && (details.DatePosted == nil || details.DatePosted.Format("2006-01-02") != tt.wantPosted) {
+				t.Errorf("Expected date posted %s, got: %v", tt.wantPosted, details.DatePosted)
+			}
+		})
+	}
+}
+
+func TestAshbyAdapter_Discover_Details(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/posting-api/job-board/acme": "ashby_job_board.json",
+	})
+
+	adapter, _ := GetATSAdapter("ashby")
+	jobs, err := adapter.Discover(context.Background(), server.Client(), CompanyConfig{
+		Name:      "Acme",
+		FetchType: "ashby",
+		URL:       server.URL + "/posting-api/job-board/acme",
+	})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("Expected 1 job reference, got: %d", len(jobs))
+	}
+
+	// The board lists every detail of its postings, so they are not enriched one by one
+	jobRef := jobs[0]
+	jobRef.CompanyName = "Acme"
+	if !jobRef.IsComplete() {
+		t.Fatalf("Expected a complete job reference, got: %+v", jobRef)
+	}
+
+	details := jobRef.ToJobDetails()
+	if details.Title != "Data Engineer" || details.Department != "Data" || details.Offices != "London, Berlin" ||
+		details.Team != "Analytics Platform" || details.WorkplaceType != "Hybrid" || details.Description != "<p>Own our pipelines.</p>" {
+		t.Errorf("Unexpected job details: %+v", details)
+	}
+	if details.CompanyName != "Acme" || details.Source != "ashby" || details.URL != jobRef.URL || !details.IsValid() {
+		t.Errorf("Unexpected job identity: %+v", details)
+	}
+}
+
+func TestWorkdayAdapter_Discover_Pagination(t *testing.T) {
+	const total = 45
+
+	var offsets []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Limit  int `json:"limit"`
+			Offset int `json:"offset"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		offsets = append(offsets, body.Offset)
+
+		var page struct {
+			Total       int              `json:"total"`
+			JobPostings []map[string]any `json:"jobPostings"`
+		}
+		// Like Workday, only report the total on the first page
+		if body.Offset == 0 {
+			page.Total = total
+		}
+		for i := body.Offset; i < total && i < body.Offset+body.Limit; i++ {
+			page.JobPostings = append(page.JobPostings, map[string]any{
+				"title":        fmt.Sprintf("Job %d", i),
+				"externalPath": fmt.Sprintf("/job/Paris/Job_R%d", i),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	adapter, _ := GetATSAdapter("workday")
+	jobs, err := adapter.Discover(context.Background(), server.Client(), CompanyConfig{
+		Name:      "Acme",
+		FetchType: "workday",
+		URL:       server.URL + "/wday/cxs/acme/External/jobs",
+	})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if len(jobs) != total {
+		t.Errorf("Expected %d job references, got: %d", total, len(jobs))
+	}
+
+	if want := []int{0, 20, 40}; fmt.Sprint(offsets) != fmt.Sprint(want) {
+		t.Errorf("Expected offsets %v, got: %v", want, offsets)
+	}
+
+	if jobs[total-1].ExternalID != "R44" || jobs[total-1].APIURL != server.URL+"/wday/cxs/acme/External/job/Paris/Job_R44" {
+		t.Errorf("Unexpected job reference: %+v", jobs[total-1])
+	}
+}
+
+func TestWorkdayJobsEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  CompanyConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "board",
+			config: CompanyConfig{Board: "acme.wd3/External"},
+			want:   "https://acme.wd3.myworkdayjobs.com/wday/cxs/acme/External/jobs",
+		},
+		{
+			name:   "url override",
+			config: CompanyConfig{Board: "acme.wd3/External", URL: "https://careers.acme.com/wday/cxs/acme/External/jobs"},
+			want:   "https://careers.acme.com/wday/cxs/acme/External/jobs",
+		},
+		{
+			name:    "board without site",
+			config:  CompanyConfig{Board: "acme.wd3"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := workdayJobsEndpoint(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("workdayJobsEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("workdayJobsEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestATSAdapterForReference(t *testing.T) {
+	tests := []struct {
+		name         string
+		jobReference models.JobReference
+		wantAdapter  bool
+	}{
+		{
+			name:         "discovered by an adapter",
+			jobReference: models.JobReference{URL: "https://job-boards.greenhouse.io/acme/jobs/1", Source: "greenhouse"},
+			wantAdapter:  true,
+		},
+		{
+			name:         "lever hosted url",
+			jobReference: models.JobReference{URL: "https://jobs.lever.co/acme/5ac21346"},
+			wantAdapter:  true,
+		},
+		{
+			name:         "company careers page",
+			jobReference: models.JobReference{URL: "https://careers.acme.com/jobs/5ac21346"},
+			wantAdapter:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := ATSAdapterForReference(&tt.jobReference); got != tt.wantAdapter {
+				t.Errorf("ATSAdapterForReference() found = %v, want %v", got, tt.wantAdapter)
+			}
+		})
+	}
+}
+
+func TestLeverAPIURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		jobReference models.JobReference
+		want         string
+	}{
+		{
+			name: "discovered through the postings API",
+			jobReference: models.JobReference{
+				URL:    "https://jobs.lever.co/acme/5ac21346",
+				Source: "lever",
+				APIURL: "https://api.lever.co/v0/postings/acme/5ac21346?mode=json",
+			},
+			want: "https://api.lever.co/v0/postings/acme/5ac21346?mode=json",
+		},
+		{
+			name:         "hosted url",
+			jobReference: models.JobReference{URL: "https://jobs.lever.co/acme/5ac21346"},
+			want:         "https://api.lever.co/v0/postings/acme/5ac21346",
+		},
+		{
+			name:         "eu hosted apply url",
+			jobReference: models.JobReference{URL: "https://jobs.eu.lever.co/acme/5ac21346/apply"},
+			want:         "https://api.eu.lever.co/v0/postings/acme/5ac21346",
+		},
+		{
+			name:         "not a lever url",
+			jobReference: models.JobReference{URL: "https://careers.acme.com/jobs/5ac21346"},
+			want:         "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := leverAPIURL(&tt.jobReference); got != tt.want {
+				t.Errorf("leverAPIURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichmentURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		jobReference models.JobReference
+		want         string
+	}{
+		{
+			name: "discovered by an adapter",
+			jobReference: models.JobReference{
+				URL:    "https://job-boards.greenhouse.io/acme/jobs/1",
+				Source: "greenhouse",
+				APIURL: "https://boards-api.greenhouse.io/v1/boards/acme/jobs/1",
+			},
+			want: "https://boards-api.greenhouse.io/v1/boards/acme/jobs/1",
+		},
+		{
+			name:         "lever hosted url discovered by html",
+			jobReference: models.JobReference{URL: "https://jobs.lever.co/acme/5ac21346", Source: "html"},
+			want:         "https://api.lever.co/v0/postings/acme/5ac21346",
+		},
+		{
+			name:         "scraped page",
+			jobReference: models.JobReference{URL: "https://careers.acme.com/jobs/5ac21346", Source: "sitemap"},
+			want:         "https://careers.acme.com/jobs/5ac21346",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EnrichmentURL(&tt.jobReference); got != tt.want {
+				t.Errorf("EnrichmentURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("workable", &workableAdapter{})
+}
+
+// workableAdapter reads postings from the Workable careers widget and jobs APIs
+type workableAdapter struct{}
+
+type workableJob struct {
+	Shortcode  string   `json:"shortcode"`
+	Title      string   `json:"title"`
+	Remote     bool     `json:"remote"`
+	Workplace  string   `json:"workplace"`
+	Type       string   `json:"type"`
+	Published  string   `json:"published"`
+	Department []string `json:"department"`
+	Location   struct {
+		City    string `json:"city"`
+		Region  string `json:"region"`
+		Country string `json:"country"`
+	} `json:"location"`
+	Locations []struct {
+		City    string `json:"city"`
+		Region  string `json:"region"`
+		Country string `json:"country"`
+	} `json:"locations"`
+	Description  string `json:"description"`
+	Requirements string `json:"requirements"`
+	Benefits     string `json:"benefits"`
+}
+
+func (a *workableAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint := atsEndpoint(config, "https://apply.workable.com/api/v1/widget/accounts/%s")
+
+	var account struct {
+		Jobs []struct {
+			Shortcode string `json:"shortcode"`
+			Title     string `json:"title"`
+			URL       string `json:"url"`
+		} `json:"jobs"`
+	}
+	if err := getJSON(ctx, client, endpoint, &account); err != nil {
+		return nil, fmt.Errorf("failed to fetch workable account: %w", err)
+	}
+
+	jobsEndpoint := workableJobsEndpoint(endpoint, config.Board)
+
+	var jobs []*models.JobReference
+	for _, job := range account.Jobs {
+		if job.Shortcode == "" || job.URL == "" {
+			continue
+		}
+
+		jobs = append(jobs, &models.JobReference{
+			ExternalID: job.Shortcode,
+			URL:        job.URL,
+			Source:     "workable",
+			APIURL:     appendPathSegment(jobsEndpoint, job.Shortcode, false),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (a *workableAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	if jobReference.APIURL == "" {
+		return nil, fmt.Errorf("no API URL for workable job %s", jobReference.ExternalID)
+	}
+
+	var job workableJob
+	if err := getJSON(ctx, client, jobReference.APIURL, &job); err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(job.Title)
+	if title == "" {
+		return nil, fmt.Errorf("could not extract job title from workable job %s", jobReference.ExternalID)
+	}
+
+	offices := make([]string, 0, len(job.Locations))
+	for _, location := range job.Locations {
+		offices = append(offices, joinNonEmpty(location.City, location.Region, location.Country))
+	}
+
+	workplaceType := job.Workplace
+	if workplaceType == "" && job.Remote {
+		workplaceType = "remote"
+	}
+
+	details := &models.JobDetails{
+		ExternalID:     jobReference.ExternalID,
+		CompanyName:    jobReference.CompanyName,
+		URL:            jobReference.URL,
+		Title:          title,
+		Location:       joinNonEmpty(job.Location.City, job.Location.Region, job.Location.Country),
+		Description:    strings.TrimSpace(job.Description + job.Requirements + job.Benefits),
+		Department:     joinNonEmpty(job.Department...),
+		Offices:        joinNonEmpty(offices...),
+		EmploymentType: job.Type,
+		WorkplaceType:  workplaceType,
+	}
+
+	if published, err := time.Parse(time.RFC3339, job.Published); err == nil {
+		details.DatePosted = &published
+	}
+
+	return details, nil
+}
+
+// workableJobsEndpoint returns the endpoint serving single postings of an account. It lives next
+// to the widget endpoint, so that overriding the company URL also overrides it.
+func workableJobsEndpoint(widgetEndpoint, account string) string {
+	u, err := url.Parse(widgetEndpoint)
+	if err != nil {
+		return ""
+	}
+	if account == "" {
+		account = u.Path[strings.LastIndex(u.Path, "/")+1:]
+	}
+	u.RawQuery = ""
+	u.Path = fmt.Sprintf("/api/v2/accounts/%s/jobs", url.PathEscape(account))
+	return u.String()
+}
@@ -0,0 +1,180 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func init() {
+	RegisterATSAdapter("workday", &workdayAdapter{})
+}
+
+// workdayPageSize is the largest page the Workday CXS job search serves
+const workdayPageSize = 20
+
+// workdayAdapter reads postings from the CXS API behind Workday career sites
+type workdayAdapter struct{}
+
+type workdayPostingInfo struct {
+	ID                  string   `json:"id"`
+	Title               string   `json:"title"`
+	JobDescription      string   `json:"jobDescription"`
+	Location            string   `json:"location"`
+	AdditionalLocations []string `json:"additionalLocations"`
+	TimeType            string   `json:"timeType"`
+	RemoteType          string   `json:"remoteType"`
+	JobReqID            string   `json:"jobReqId"`
+	StartDate           string   `json:"startDate"`
+	ExternalURL         string   `json:"externalUrl"`
+}
+
+func (a *workdayAdapter) Discover(ctx context.Context, client *http.Client, config CompanyConfig) ([]*models.JobReference, error) {
+	endpoint, err := workdayJobsEndpoint(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cxsBase, siteBase, err := workdayBases(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*models.JobReference
+	total := 0
+	for offset := 0; ; offset += workdayPageSize {
+		body := map[string]any{
+			"appliedFacets": map[string]any{},
+			"limit":         workdayPageSize,
+			"offset":        offset,
+			"searchText":    "",
+		}
+
+		var page struct {
+			Total       int `json:"total"`
+			JobPostings []struct {
+				Title         string   `json:"title"`
+				ExternalPath  string   `json:"externalPath"`
+				LocationsText string   `json:"locationsText"`
+				BulletFields  []string `json:"bulletFields"`
+			} `json:"jobPostings"`
+		}
+		if err := doJSON(ctx, client, "POST", endpoint, body, &page); err != nil {
+			return nil, fmt.Errorf("failed to fetch workday postings: %w", err)
+		}
+
+		// Workday only reports the total on the first page, later pages return 0
+		if offset == 0 {
+			total = page.Total
+		}
+
+		for _, posting := range page.JobPostings {
+			if posting.ExternalPath == "" {
+				continue
+			}
+
+			jobs = append(jobs, &models.JobReference{
+				ExternalID: workdayExternalID(posting.ExternalPath),
+				URL:        siteBase + posting.ExternalPath,
+				Source:     "workday",
+				APIURL:     cxsBase + posting.ExternalPath,
+			})
+		}
+
+		if len(page.JobPostings) == 0 || offset+len(page.JobPostings) >= total {
+			break
+		}
+	}
+
+	return jobs, nil
+}
+
+func (a *workdayAdapter) Enrich(ctx context.Context, client *http.Client, jobReference *models.JobReference) (*models.JobDetails, error) {
+	if jobReference.APIURL == "" {
+		return nil, fmt.Errorf("no API URL for workday job %s", jobReference.ExternalID)
+	}
+
+	var job struct {
+		JobPostingInfo workdayPostingInfo `json:"jobPostingInfo"`
+	}
+	if err := getJSON(ctx, client, jobReference.APIURL, &job); err != nil {
+		return nil, err
+	}
+
+	posting := job.JobPostingInfo
+	title := strings.TrimSpace(posting.Title)
+	if title == "" {
+		return nil, fmt.Errorf("could not extract job title from workday posting %s", jobReference.ExternalID)
+	}
+
+	details := &models.JobDetails{
+		ExternalID:     jobReference.ExternalID,
+		CompanyName:    jobReference.CompanyName,
+		URL:            jobReference.URL,
+		Title:          title,
+		Location:       posting.Location,
+		Description:    strings.TrimSpace(posting.JobDescription),
+		Offices:        joinNonEmpty(append([]string{posting.Location}, posting.AdditionalLocations...)...),
+		EmploymentType: posting.TimeType,
+		WorkplaceType:  posting.RemoteType,
+	}
+
+	// The start date of a Workday posting is when it was posted, not when it was last updated
+	if startDate, err := time.Parse("2006-01-02", posting.StartDate); err == nil {
+		details.DatePosted = &startDate
+	}
+
+	return details, nil
+}
+
+// workdayJobsEndpoint returns the CXS job search endpoint of a career site. Boards are written
+// as "<tenant>.<wdN>/<site>", as in https://<tenant>.<wdN>.myworkdayjobs.com/<site>
+func workdayJobsEndpoint(config CompanyConfig) (string, error) {
+	if config.URL != "" {
+		return config.URL, nil
+	}
+
+	host, site, found := strings.Cut(config.Board, "/")
+	tenant, _, hasInstance := strings.Cut(host, ".")
+	if !found || !hasInstance || tenant == "" || site == "" {
+		return "", fmt.Errorf("invalid workday board %q, expected <tenant>.<wdN>/<site>", config.Board)
+	}
+
+	return fmt.Sprintf("https://%s.myworkdayjobs.com/wday/cxs/%s/%s/jobs", host, tenant, site), nil
+}
+
+// workdayBases derives, from the job search endpoint /wday/cxs/<tenant>/<site>/jobs, the base
+// of the posting detail endpoints and the base of the public posting URLs
+func workdayBases(endpoint string) (cxsBase, siteBase string, err error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid workday endpoint: %w", err)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 5 || segments[len(segments)-1] != "jobs" {
+		return "", "", fmt.Errorf("invalid workday endpoint %q, expected /wday/cxs/<tenant>/<site>/jobs", endpoint)
+	}
+
+	origin := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	cxsBase = origin + "/" + strings.Join(segments[:len(segments)-1], "/")
+	siteBase = origin + "/" + segments[len(segments)-2]
+
+	return cxsBase, siteBase, nil
+}
+
+// workdayExternalID extracts the requisition ID ending the posting path, as in
+// /job/Paris/Software-Engineer_JR123, or the last path segment when there is none
+func workdayExternalID(externalPath string) string {
+	slug := path.Base(externalPath)
+	if i := strings.LastIndex(slug, "_"); i >= 0 && i < len(slug)-1 {
+		return slug[i+1:]
+	}
+	return slug
+}
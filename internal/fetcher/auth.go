@@ -0,0 +1,109 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/secrets"
+)
+
+// AuthConfig authenticates the requests sent to the host of the company URL. Credentials accept
+// ${ENV_VAR} and ${file:/path} secret references, like headers and request bodies.
+type AuthConfig struct {
+	Type string `yaml:"type"` // bearer, basic or oauth2_client_credentials
+
+	Token    string `yaml:"token,omitempty"`    // Token of bearer authentication
+	Username string `yaml:"username,omitempty"` // Credentials of basic authentication
+	Password string `yaml:"password,omitempty"`
+
+	// Token endpoint and client credentials of the OAuth2 client credentials grant, with the scopes requested
+	TokenURL     string   `yaml:"token_url,omitempty"`
+	ClientID     string   `yaml:"client_id,omitempty"`
+	ClientSecret string   `yaml:"client_secret,omitempty"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+}
+
+// Validate checks that the credentials required by the authentication type are set
+func (a *AuthConfig) Validate() error {
+	switch a.Type {
+	case "bearer":
+		if a.Token == "" {
+			return fmt.Errorf("token is required for bearer auth")
+		}
+	case "basic":
+		if a.Username == "" {
+			return fmt.Errorf("username is required for basic auth")
+		}
+	case "oauth2_client_credentials":
+		if a.TokenURL == "" || a.ClientID == "" || a.ClientSecret == "" {
+			return fmt.Errorf("token_url, client_id and client_secret are required for oauth2_client_credentials auth")
+		}
+		if parsed, err := url.Parse(a.TokenURL); err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid token_url: %s", a.TokenURL)
+		}
+	default:
+		return fmt.Errorf("invalid auth type: %s", a.Type)
+	}
+	return nil
+}
+
+// Authenticator creates the authenticator of the configuration, OAuth2 tokens being requested with client
+func (a *AuthConfig) Authenticator(client *http.Client) httpclient.Authenticator {
+	switch a.Type {
+	case "bearer":
+		return httpclient.NewBearerAuth(a.Token)
+	case "basic":
+		return httpclient.NewBasicAuth(a.Username, a.Password)
+	case "oauth2_client_credentials":
+		return httpclient.NewClientCredentialsAuth(client, a.TokenURL, a.ClientID, a.ClientSecret, a.Scopes)
+	}
+	return nil
+}
+
+// resolveSecrets replaces the secret references of the company URL, headers, request body and credentials.
+// The secret credentials are redacted from the logs, whether they are references or written in the configuration.
+func (c *CompanyConfig) resolveSecrets() error {
+	type value struct {
+		field  string
+		value  *string
+		secret bool
+	}
+	values := []value{
+		{"url", &c.URL, false},
+		{"request_body", &c.RequestBody, false},
+	}
+	if c.Auth != nil {
+		values = append(values, []value{
+			{"auth.token", &c.Auth.Token, true},
+			{"auth.username", &c.Auth.Username, false},
+			{"auth.password", &c.Auth.Password, true},
+			{"auth.token_url", &c.Auth.TokenURL, false},
+			{"auth.client_id", &c.Auth.ClientID, false},
+			{"auth.client_secret", &c.Auth.ClientSecret, true},
+		}...)
+	}
+
+	for _, v := range values {
+		resolved, err := secrets.Resolve(*v.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", v.field, err)
+		}
+		*v.value = resolved
+		if v.secret {
+			logger.RegisterSecret(resolved)
+		}
+	}
+
+	for key, value := range c.Headers {
+		resolved, err := secrets.Resolve(value)
+		if err != nil {
+			return fmt.Errorf("invalid header %s: %w", key, err)
+		}
+		c.Headers[key] = resolved
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+func TestCompanyConfig_ResolveSecrets_RedactsCredentials(t *testing.T) {
+	t.Setenv("TEST_CLIENT_SECRET", "client-secret-from-env")
+
+	config := &CompanyConfig{
+		Name:      "test",
+		FetchType: "api",
+		URL:       "https://api.example.com/jobs",
+		Auth: &AuthConfig{
+			Type:         "oauth2_client_credentials",
+			Token:        "literal-token",
+			Password:     "literal-password",
+			TokenURL:     "https://auth.example.com/oauth/token",
+			ClientID:     "client-id",
+			ClientSecret: "${TEST_CLIENT_SECRET}",
+		},
+	}
+	if err := config.resolveSecrets(); err != nil {
+		t.Fatalf("resolveSecrets() error = %v", err)
+	}
+
+	// Credentials written in the configuration are redacted like the ones referenced
+	for _, secret := range []string{"literal-token", "literal-password", "client-secret-from-env"} {
+		if got := logger.Redact("credential " + secret); got != "credential "+logger.Redacted {
+			t.Errorf("Redact(%q) = %q, want it redacted", secret, got)
+		}
+	}
+	if got := logger.Redact("client-id"); got != "client-id" {
+		t.Errorf("Redact(client-id) = %q, want the client ID logged", got)
+	}
+}
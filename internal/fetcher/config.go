@@ -3,34 +3,109 @@ package fetcher
 import (
 	"fmt"
 	"os"
+	"path"
 	"regexp"
+	"time"
 
+	"github.com/gkettani/bobber-the-swe/internal/jsonquery"
+	"github.com/gkettani/bobber-the-swe/internal/schedule"
 	"gopkg.in/yaml.v3"
 )
 
 // CompanyConfig holds all the configuration needed to fetch jobs for a company
 type CompanyConfig struct {
-	Name         string            `yaml:"name"`
-	FetchType    string            `yaml:"fetch_type"`
-	URL          string            `yaml:"url"`
-	IDPattern    string            `yaml:"id_pattern"`
-	LinkSelector string            `yaml:"link_selector,omitempty"`
-	Method       string            `yaml:"method,omitempty"`
-	Headers      map[string]string `yaml:"headers,omitempty"`
-	RequestBody  string            `yaml:"request_body,omitempty"`
-	Enabled      bool              `yaml:"enabled,omitempty"`
-
-	// API response configuration
-	JobsPath    string `yaml:"jobs_path,omitempty"`    // JSON path to jobs array
-	IDField     string `yaml:"id_field,omitempty"`     // Field name for job ID
-	URLTemplate string `yaml:"url_template,omitempty"` // Template for job URLs
-
-	// Compiled regex pattern (not serialized)
-	compiledPattern *regexp.Regexp `yaml:"-"`
+	Name      string `yaml:"name"`
+	FetchType string `yaml:"fetch_type"`
+	URL       string `yaml:"url"`
+	Board     string `yaml:"board,omitempty"` // ATS board token or company slug, for ATS fetch types
+	IDPattern string `yaml:"id_pattern"`      // Regex capturing the job ID in posting URLs, feeds fall back to the entry ID when empty
+	// Regex filtering the child sitemaps followed from a sitemap index, all are followed when empty
+	SitemapPattern string            `yaml:"sitemap_pattern,omitempty"`
+	LinkSelector   string            `yaml:"link_selector,omitempty"`
+	Method         string            `yaml:"method,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"`      // Header values accept ${ENV_VAR} and ${file:/path} secret references
+	RequestBody    string            `yaml:"request_body,omitempty"` // Accepts secret references like headers
+	Enabled        bool              `yaml:"enabled,omitempty"`
+
+	// Authentication of the requests sent to the host of the company URL
+	Auth *AuthConfig `yaml:"auth,omitempty"`
+
+	// API response configuration, paths are JMESPath expressions (https://jmespath.org)
+	JobsPath        string `yaml:"jobs_path,omitempty"`        // Path to jobs array, nested arrays are flattened
+	IDField         string `yaml:"id_field,omitempty"`         // Field name for job ID
+	IDPath          string `yaml:"id_path,omitempty"`          // Path to job ID within a job, takes precedence over id_field
+	URLPath         string `yaml:"url_path,omitempty"`         // Path to job URL within a job, url_template is used when missing
+	TitlePath       string `yaml:"title_path,omitempty"`       // Path to job title within a job
+	LocationPath    string `yaml:"location_path,omitempty"`    // Path to job location within a job
+	DescriptionPath string `yaml:"description_path,omitempty"` // Path to job description within a job, with title_path enrichment is skipped
+	URLTemplate     string `yaml:"url_template,omitempty"`     // Template for job URLs
+
+	// Location of the JSON state in the page, for embedded_json fetch type
+	EmbeddedJSONSource `yaml:",inline"`
+
+	// Query parameters removed from job URLs, a trailing * matching any suffix. DefaultStripQueryParams
+	// are removed when unset, and none with an empty list.
+	StripQueryParams []string `yaml:"strip_query_params,omitempty"`
+
+	// Pagination of API and HTML listings, a single page is fetched when unset
+	Pagination *PaginationConfig `yaml:"pagination,omitempty"`
+
+	// When the company is discovered, the default discovery interval applying when unset
+	Schedule *ScheduleConfig `yaml:"schedule,omitempty"`
+
+	// Compiled regex patterns (not serialized)
+	compiledPattern        *regexp.Regexp `yaml:"-"`
+	compiledSitemapPattern *regexp.Regexp `yaml:"-"`
 }
 
+// ScheduleConfig sets when a company is discovered, either with a cron expression or at an interval
+type ScheduleConfig struct {
+	Cron     string        `yaml:"cron,omitempty"`     // Five-field cron expression, in the server time zone
+	Interval time.Duration `yaml:"interval,omitempty"` // Time between the end of a discovery and the next one
+	Jitter   time.Duration `yaml:"jitter,omitempty"`   // Random delay up to which each run is pushed back
+}
+
+// minScheduleInterval keeps interval schedules from hammering career pages
+const minScheduleInterval = time.Minute
+
+// Validate checks that exactly one of cron and interval is set
+func (s *ScheduleConfig) Validate() error {
+	if (s.Cron == "") == (s.Interval == 0) {
+		return fmt.Errorf("one of cron and interval is required")
+	}
+	if s.Jitter < 0 {
+		return fmt.Errorf("jitter must not be negative")
+	}
+	if s.Interval != 0 && s.Interval < minScheduleInterval {
+		return fmt.Errorf("interval must be at least %v", minScheduleInterval)
+	}
+	if s.Cron != "" {
+		cron, err := schedule.ParseCron(s.Cron)
+		if err != nil {
+			return err
+		}
+		if cron.Next(time.Now()).IsZero() {
+			return fmt.Errorf("cron expression %q never matches", s.Cron)
+		}
+	}
+	return nil
+}
+
+// Schedule returns the schedule of a validated configuration
+func (s *ScheduleConfig) Schedule() schedule.Schedule {
+	if s.Cron != "" {
+		cron, _ := schedule.ParseCron(s.Cron)
+		return schedule.WithJitter(cron, s.Jitter)
+	}
+	return schedule.Every(s.Interval, s.Jitter)
+}
+
+// DefaultConcurrency is the number of companies fetched at once when the configuration does not set it
+const DefaultConcurrency = 8
+
 type FetcherConfig struct {
-	Companies map[string]CompanyConfig `yaml:"companies"`
+	Concurrency int                      `yaml:"concurrency,omitempty"` // Maximum number of companies fetched at once
+	Companies   map[string]CompanyConfig `yaml:"companies"`
 }
 
 func LoadConfig(configPath string) (*FetcherConfig, error) {
@@ -45,6 +120,10 @@ func LoadConfig(configPath string) (*FetcherConfig, error) {
 	}
 
 	for key, companyConfig := range config.Companies {
+		if err := companyConfig.resolveSecrets(); err != nil {
+			return nil, fmt.Errorf("invalid config for company %s: %w", key, err)
+		}
+
 		if companyConfig.IDPattern != "" {
 			pattern, err := regexp.Compile(companyConfig.IDPattern)
 			if err != nil {
@@ -53,6 +132,14 @@ func LoadConfig(configPath string) (*FetcherConfig, error) {
 			companyConfig.compiledPattern = pattern
 		}
 
+		if companyConfig.SitemapPattern != "" {
+			pattern, err := regexp.Compile(companyConfig.SitemapPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sitemap pattern for company %s: %w", key, err)
+			}
+			companyConfig.compiledSitemapPattern = pattern
+		}
+
 		if err := companyConfig.Validate(); err != nil {
 			return nil, fmt.Errorf("invalid config for company %s: %w", key, err)
 		}
@@ -67,28 +154,97 @@ func (c *CompanyConfig) GetCompiledPattern() *regexp.Regexp {
 	return c.compiledPattern
 }
 
+func (c *CompanyConfig) GetCompiledSitemapPattern() *regexp.Regexp {
+	return c.compiledSitemapPattern
+}
+
+// GetStripQueryParams returns the query parameters removed from job URLs
+func (c *CompanyConfig) GetStripQueryParams() []string {
+	if c.StripQueryParams == nil {
+		return DefaultStripQueryParams
+	}
+	return c.StripQueryParams
+}
+
 func (c *CompanyConfig) Validate() error {
 	if c.Name == "" {
 		return fmt.Errorf("company name is required")
 	}
 
-	if c.URL == "" {
-		return fmt.Errorf("company URL is required")
-	}
+	_, isATS := GetATSAdapter(c.FetchType)
 
 	switch c.FetchType {
-	case "sitemap", "html", "api":
+	case "sitemap", "html", "api", "embedded_json", "feed":
 	default:
-		return fmt.Errorf("invalid fetch type: %s", c.FetchType)
+		if !isATS {
+			return fmt.Errorf("invalid fetch type: %s", c.FetchType)
+		}
+	}
+
+	if isATS {
+		if c.Board == "" && c.URL == "" {
+			return fmt.Errorf("board is required for %s fetch type", c.FetchType)
+		}
+	} else if c.URL == "" {
+		return fmt.Errorf("company URL is required")
 	}
 
 	if c.FetchType == "html" && c.LinkSelector == "" {
 		return fmt.Errorf("link_selector is required for HTML fetch type")
 	}
 
-	if c.FetchType == "api" {
-		if c.Method == "" {
-			return fmt.Errorf("method is required for API fetch type")
+	if c.FetchType == "api" && c.Method == "" {
+		return fmt.Errorf("method is required for API fetch type")
+	}
+
+	for _, param := range c.StripQueryParams {
+		if _, err := path.Match(param, ""); err != nil {
+			return fmt.Errorf("invalid strip_query_params entry %q: %w", param, err)
+		}
+	}
+
+	if c.FetchType == "embedded_json" {
+		if err := c.EmbeddedJSONSource.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.FetchType == "api" || c.FetchType == "embedded_json" {
+		for field, path := range map[string]string{
+			"jobs_path":        c.JobsPath,
+			"id_path":          c.IDPath,
+			"url_path":         c.URLPath,
+			"title_path":       c.TitlePath,
+			"location_path":    c.LocationPath,
+			"description_path": c.DescriptionPath,
+		} {
+			if path == "" {
+				continue
+			}
+			if err := jsonquery.Validate(path); err != nil {
+				return fmt.Errorf("invalid %s: %w", field, err)
+			}
+		}
+	}
+
+	if c.Auth != nil {
+		if isATS {
+			return fmt.Errorf("auth is not supported for %s fetch type", c.FetchType)
+		}
+		if err := c.Auth.Validate(); err != nil {
+			return fmt.Errorf("invalid auth: %w", err)
+		}
+	}
+
+	if c.Pagination != nil {
+		if err := c.Pagination.Validate(c.FetchType); err != nil {
+			return fmt.Errorf("invalid pagination: %w", err)
+		}
+	}
+
+	if c.Schedule != nil {
+		if err := c.Schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
 		}
 	}
 
@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// EmbeddedJSONSource locates the JSON state embedded in a page by client-side rendered sites
+type EmbeddedJSONSource struct {
+	ScriptSelector string `yaml:"script_selector,omitempty"` // CSS selector of a script holding JSON, e.g. script#__NEXT_DATA__
+	ScriptPattern  string `yaml:"script_pattern,omitempty"`  // Regex whose first group captures the JSON assigned in a script
+}
+
+// Validate checks that the source locates the JSON in exactly one way
+func (s EmbeddedJSONSource) Validate() error {
+	if (s.ScriptSelector == "") == (s.ScriptPattern == "") {
+		return fmt.Errorf("either script_selector or script_pattern is required")
+	}
+
+	if s.ScriptPattern != "" {
+		pattern, err := regexp.Compile(s.ScriptPattern)
+		if err != nil {
+			return fmt.Errorf("invalid script_pattern: %w", err)
+		}
+		if pattern.NumSubexp() < 1 {
+			return fmt.Errorf("script_pattern must capture the JSON in a group")
+		}
+	}
+
+	return nil
+}
+
+// Extract decodes the JSON embedded in a page
+func (s EmbeddedJSONSource) Extract(doc *goquery.Document) (interface{}, error) {
+	var blob string
+
+	if s.ScriptSelector != "" {
+		blob = strings.TrimSpace(doc.Find(s.ScriptSelector).First().Text())
+		if blob == "" {
+			return nil, fmt.Errorf("no embedded JSON found with selector: %s", s.ScriptSelector)
+		}
+	} else {
+		pattern, err := regexp.Compile(s.ScriptPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script_pattern: %w", err)
+		}
+
+		doc.Find("script").EachWithBreak(func(i int, script *goquery.Selection) bool {
+			if matches := pattern.FindStringSubmatch(script.Text()); len(matches) > 1 {
+				blob = strings.TrimSpace(matches[1])
+				return false
+			}
+			return true
+		})
+		if blob == "" {
+			return nil, fmt.Errorf("no embedded JSON found with pattern: %s", s.ScriptPattern)
+		}
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(blob), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded JSON: %w", err)
+	}
+
+	return data, nil
+}
+
+func (f *JobFetcher) fetchFromEmbeddedJSON(ctx context.Context, config CompanyConfig) ([]*models.JobReference, fetchStats, error) {
+	return f.fetchPages(ctx, config, func(resp *http.Response) ([]*models.JobReference, pageResult, error) {
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			return nil, pageResult{}, fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		doc.Url = resp.Request.URL
+
+		data, err := config.EmbeddedJSONSource.Extract(doc)
+		if err != nil {
+			return nil, pageResult{}, err
+		}
+
+		jobs, err := f.parseAPIResponse(config, data)
+		if err != nil {
+			return nil, pageResult{}, err
+		}
+
+		return jobs, pageResult{Header: resp.Header, Data: data, Doc: doc, Count: len(jobs)}, nil
+	})
+}
@@ -0,0 +1,112 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJobFetcher_FetchJobs_EmbeddedJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/next":
+			fmt.Fprint(w, `<html><body><div id="__next"></div>
+<script id="__NEXT_DATA__" type="application/json">{"props": {"pageProps": {"jobs": [
+	{"slug": "backend-engineer", "title": "Backend Engineer"},
+	{"slug": "data-engineer", "title": "Data Engineer"}
+]}}}</script></body></html>`)
+		case "/state":
+			fmt.Fprint(w, `<html><body><script>var analytics = {"id": 1};</script>
+<script>window.__INITIAL_STATE__ = {"careers": {"openings": [{"id": 42, "url": "https://acme.com/careers/42"}]}};</script>
+</body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		config  CompanyConfig
+		wantID  string
+		wantURL string
+		wantLen int
+	}{
+		{
+			name: "next data script",
+			config: CompanyConfig{
+				URL:                server.URL + "/next",
+				EmbeddedJSONSource: EmbeddedJSONSource{ScriptSelector: "script#__NEXT_DATA__"},
+				JobsPath:           "props.pageProps.jobs",
+				IDPath:             "slug",
+				TitlePath:          "title",
+				URLTemplate:        "https://acme.com/jobs/{id}",
+			},
+			wantID:  "backend-engineer",
+			wantURL: "https://acme.com/jobs/backend-engineer",
+			wantLen: 2,
+		},
+		{
+			name: "javascript assignment",
+			config: CompanyConfig{
+				URL:                server.URL + "/state",
+				EmbeddedJSONSource: EmbeddedJSONSource{ScriptPattern: `window\.__INITIAL_STATE__\s*=\s*(\{.*\});`},
+				JobsPath:           "careers.openings",
+				URLPath:            "url",
+			},
+			wantID:  "42",
+			wantURL: "https://acme.com/careers/42",
+			wantLen: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Name = tt.name
+			tt.config.FetchType = "embedded_json"
+
+			fetcher := NewJobFetcher()
+			if err := fetcher.RegisterCompany(tt.config); err != nil {
+				t.Fatalf("Failed to register company: %v", err)
+			}
+
+			jobs, err := fetcher.FetchJobs(context.Background(), tt.name)
+			if err != nil {
+				t.Fatalf("FetchJobs() error = %v", err)
+			}
+
+			if len(jobs) != tt.wantLen {
+				t.Fatalf("Expected %d jobs, got: %d", tt.wantLen, len(jobs))
+			}
+
+			if jobs[0].ExternalID != tt.wantID || jobs[0].URL != tt.wantURL {
+				t.Errorf("Unexpected job reference: %+v", jobs[0])
+			}
+		})
+	}
+}
+
+func TestEmbeddedJSONSource_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  EmbeddedJSONSource
+		wantErr bool
+	}{
+		{name: "selector", source: EmbeddedJSONSource{ScriptSelector: "script#__NEXT_DATA__"}},
+		{name: "pattern", source: EmbeddedJSONSource{ScriptPattern: `__STATE__ = (\{.*\});`}},
+		{name: "missing", source: EmbeddedJSONSource{}, wantErr: true},
+		{name: "both", source: EmbeddedJSONSource{ScriptSelector: "script", ScriptPattern: `(\{.*\})`}, wantErr: true},
+		{name: "pattern without group", source: EmbeddedJSONSource{ScriptPattern: `__STATE__ = \{.*\};`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.source.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// feedEntry is an entry of an RSS 2.0, Atom or JSON Feed document
+type feedEntry struct {
+	ID          string
+	Link        string
+	Title       string
+	Description string
+	Published   *time.Time
+	Updated     *time.Time
+}
+
+type rssDocument struct {
+	Items []struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		GUID        string `xml:"guid"`
+		PubDate     string `xml:"pubDate"`
+		Description string `xml:"description"`
+		Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	} `xml:"channel>item"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the text content, or the markup of xhtml content
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+type atomDocument struct {
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string   `xml:"published"`
+		Updated   string   `xml:"updated"`
+		Summary   atomText `xml:"summary"`
+		Content   atomText `xml:"content"`
+	} `xml:"entry"`
+}
+
+type jsonFeedDocument struct {
+	Items []struct {
+		ID            json.RawMessage `json:"id"`
+		URL           string          `json:"url"`
+		Title         string          `json:"title"`
+		ContentHTML   string          `json:"content_html"`
+		ContentText   string          `json:"content_text"`
+		Summary       string          `json:"summary"`
+		DatePublished string          `json:"date_published"`
+		DateModified  string          `json:"date_modified"`
+	} `json:"items"`
+}
+
+func (f *JobFetcher) fetchFromFeed(ctx context.Context, config CompanyConfig) ([]*models.JobReference, fetchStats, error) {
+	var stats fetchStats
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.URL, nil)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, stats, err
+	}
+	stats.Pages++
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, stats, fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	entries, err := parseFeed(body)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	var jobs []*models.JobReference
+	for _, entry := range entries {
+		if entry.Link == "" {
+			continue
+		}
+
+		// Entries are identified by their link, as for sitemaps, or by their own ID without id_pattern
+		externalID := entry.ID
+		if pattern := config.GetCompiledPattern(); pattern != nil {
+			externalID = f.extractID(entry.Link, pattern)
+		}
+		if externalID == "" {
+			continue
+		}
+
+		lastModified := entry.Updated
+		if lastModified == nil {
+			lastModified = entry.Published
+		}
+
+		jobs = append(jobs, &models.JobReference{
+			ExternalID:   externalID,
+			URL:          entry.Link,
+			Title:        entry.Title,
+			Description:  entry.Description,
+			DatePosted:   entry.Published,
+			LastModified: lastModified,
+		})
+	}
+
+	return jobs, stats, nil
+}
+
+// parseFeed reads the entries of an RSS 2.0, Atom or JSON Feed document
+func parseFeed(body []byte) ([]feedEntry, error) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("{")) {
+		return parseJSONFeed(body)
+	}
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	switch root.XMLName.Local {
+	case "rss":
+		return parseRSS(body)
+	case "feed":
+		return parseAtom(body)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root.XMLName.Local)
+	}
+}
+
+func parseRSS(body []byte) ([]feedEntry, error) {
+	var rss rssDocument
+	if err := xml.Unmarshal(body, &rss); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+	}
+
+	entries := make([]feedEntry, 0, len(rss.Items))
+	for _, item := range rss.Items {
+		link := strings.TrimSpace(item.Link)
+		entries = append(entries, feedEntry{
+			ID:          firstNonEmpty(strings.TrimSpace(item.GUID), link),
+			Link:        link,
+			Title:       strings.TrimSpace(item.Title),
+			Description: firstNonEmpty(strings.TrimSpace(item.Content), strings.TrimSpace(item.Description)),
+			Published:   parseFeedDate(item.PubDate),
+		})
+	}
+
+	return entries, nil
+}
+
+func parseAtom(body []byte) ([]feedEntry, error) {
+	var atom atomDocument
+	if err := xml.Unmarshal(body, &atom); err != nil {
+		return nil, fmt.Errorf("failed to parse Atom feed: %w", err)
+	}
+
+	entries := make([]feedEntry, 0, len(atom.Entries))
+	for _, entry := range atom.Entries {
+		var link string
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = strings.TrimSpace(l.Href)
+				break
+			}
+		}
+
+		entries = append(entries, feedEntry{
+			ID:          firstNonEmpty(strings.TrimSpace(entry.ID), link),
+			Link:        link,
+			Title:       strings.TrimSpace(entry.Title),
+			Description: firstNonEmpty(entry.Content.String(), entry.Summary.String()),
+			Published:   parseFeedDate(entry.Published),
+			Updated:     parseFeedDate(entry.Updated),
+		})
+	}
+
+	return entries, nil
+}
+
+func parseJSONFeed(body []byte) ([]feedEntry, error) {
+	var feed jsonFeedDocument
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON feed: %w", err)
+	}
+
+	entries := make([]feedEntry, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		// JSON Feed 1.0 allowed numeric IDs
+		id := strings.Trim(string(item.ID), `"`)
+		link := strings.TrimSpace(item.URL)
+
+		entries = append(entries, feedEntry{
+			ID:          firstNonEmpty(strings.TrimSpace(id), link),
+			Link:        link,
+			Title:       strings.TrimSpace(item.Title),
+			Description: firstNonEmpty(strings.TrimSpace(item.ContentHTML), strings.TrimSpace(item.ContentText), strings.TrimSpace(item.Summary)),
+			Published:   parseFeedDate(item.DatePublished),
+			Updated:     parseFeedDate(item.DateModified),
+		})
+	}
+
+	return entries, nil
+}
+
+// feedDateLayouts are the RFC 822 variants found in RSS and the RFC 3339 dates of Atom and JSON Feed
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+func parseFeedDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
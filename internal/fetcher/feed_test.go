@@ -0,0 +1,158 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Acme Careers</title>
+    <item>
+      <title>Backend Engineer</title>
+      <link>https://careers.acme.com/jobs/101</link>
+      <guid isPermaLink="false">job-101</guid>
+      <pubDate>Mon, 10 Mar 2025 09:00:00 +0000</pubDate>
+      <description>Short summary</description>
+      <content:encoded><![CDATA[<p>Build our APIs</p>]]></content:encoded>
+    </item>
+    <item>
+      <title>Acme Blog: We are hiring</title>
+      <link>https://acme.com/blog/hiring</link>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Acme Careers</title>
+  <entry>
+    <id>urn:uuid:101</id>
+    <title>Backend Engineer</title>
+    <link rel="alternate" href="https://careers.acme.com/jobs/101"/>
+    <published>2025-03-10T09:00:00Z</published>
+    <updated>2025-03-12T09:00:00Z</updated>
+    <summary type="html">&lt;p&gt;Build our APIs&lt;/p&gt;</summary>
+  </entry>
+</feed>`
+
+const jsonFeed = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Acme Careers",
+  "items": [
+    {
+      "id": "101",
+      "url": "https://careers.acme.com/jobs/101",
+      "title": "Backend Engineer",
+      "content_html": "<p>Build our APIs</p>",
+      "date_published": "2025-03-10T09:00:00Z"
+    }
+  ]
+}`
+
+func TestJobFetcher_FetchJobs_Feed(t *testing.T) {
+	published := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		feed            string
+		idPattern       string
+		wantID          string
+		wantDescription string
+		wantModified    time.Time
+	}{
+		{
+			name:            "rss",
+			feed:            rssFeed,
+			idPattern:       `/jobs/(\d+)`,
+			wantID:          "101",
+			wantDescription: "<p>Build our APIs</p>",
+			wantModified:    published,
+		},
+		{
+			name:            "rss without id_pattern",
+			feed:            rssFeed,
+			wantID:          "job-101",
+			wantDescription: "<p>Build our APIs</p>",
+			wantModified:    published,
+		},
+		{
+			name:            "atom",
+			feed:            atomFeed,
+			idPattern:       `/jobs/(\d+)`,
+			wantID:          "101",
+			wantDescription: "<p>Build our APIs</p>",
+			wantModified:    time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "json feed",
+			feed:            jsonFeed,
+			idPattern:       `/jobs/(\d+)`,
+			wantID:          "101",
+			wantDescription: "<p>Build our APIs</p>",
+			wantModified:    published,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.feed)
+			}))
+			defer server.Close()
+
+			config := CompanyConfig{
+				Name:      "Acme",
+				FetchType: "feed",
+				URL:       server.URL,
+			}
+			if tt.idPattern != "" {
+				config.compiledPattern = regexp.MustCompile(tt.idPattern)
+			}
+
+			fetcher := NewJobFetcher()
+			if err := fetcher.RegisterCompany(config); err != nil {
+				t.Fatalf("Failed to register company: %v", err)
+			}
+
+			jobs, err := fetcher.FetchJobs(context.Background(), "Acme")
+			if err != nil {
+				t.Fatalf("FetchJobs() error = %v", err)
+			}
+
+			// Entries whose link does not match id_pattern, like blog posts, are skipped
+			if tt.idPattern != "" && len(jobs) != 1 {
+				t.Fatalf("Expected 1 job, got %d", len(jobs))
+			}
+
+			job := jobs[0]
+			if job.ExternalID != tt.wantID {
+				t.Errorf("Expected ID %q, got: %q", tt.wantID, job.ExternalID)
+			}
+			if job.URL != "https://careers.acme.com/jobs/101" {
+				t.Errorf("Unexpected URL: %s", job.URL)
+			}
+			if job.Title != "Backend Engineer" {
+				t.Errorf("Unexpected title: %s", job.Title)
+			}
+			if job.Description != tt.wantDescription {
+				t.Errorf("Expected description %q, got: %q", tt.wantDescription, job.Description)
+			}
+			if job.DatePosted == nil || !job.DatePosted.Equal(published) {
+				t.Errorf("Expected date posted %v, got: %v", published, job.DatePosted)
+			}
+			if job.LastModified == nil || !job.LastModified.Equal(tt.wantModified) {
+				t.Errorf("Expected last modification %v, got: %v", tt.wantModified, job.LastModified)
+			}
+			if !job.IsComplete() {
+				t.Errorf("Expected feed entry to be complete")
+			}
+		})
+	}
+}
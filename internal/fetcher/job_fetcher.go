@@ -1,20 +1,27 @@
 package fetcher
 
 import (
+	"context"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gkettani/bobber-the-swe/internal/cache"
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/jsonquery"
 	"github.com/gkettani/bobber-the-swe/internal/logger"
 	"github.com/gkettani/bobber-the-swe/internal/metrics"
 	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/schedule"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -22,6 +29,23 @@ type JobFetcher struct {
 	httpClient *http.Client
 	companies  map[string]CompanyConfig
 	metrics    *JobFetcherMetrics
+
+	// ETag and Last-Modified of the listings, to request them conditionally
+	validators cache.Manager
+
+	// Authenticators of the companies with an auth block, caching their access tokens between fetches
+	authenticators map[string]httpclient.Authenticator
+
+	// Maximum number of companies fetched at once by concurrent FetchJobs calls
+	concurrency int
+	slots       chan struct{}
+	slotsOnce   sync.Once
+
+	// Start time of the previous successful fetch per company, to detect unchanged postings, and the
+	// companies whose previous successful fetch reached max_pages
+	lastFetch   map[string]time.Time
+	truncated   map[string]bool
+	lastFetchMu sync.RWMutex
 }
 
 type JobFetcherMetrics struct {
@@ -29,6 +53,14 @@ type JobFetcherMetrics struct {
 	fetchTotal    *prometheus.CounterVec
 	fetchErrors   *prometheus.CounterVec
 	jobsFound     *prometheus.GaugeVec
+	pagesFetched  *prometheus.CounterVec
+	notModified   *prometheus.CounterVec
+}
+
+// fetchStats describes the listing requests made during a fetch
+type fetchStats struct {
+	Pages     int
+	Truncated bool // max_pages was reached while more pages were available
 }
 
 func NewJobFetcher() *JobFetcher {
@@ -55,19 +87,27 @@ func NewJobFetcher() *JobFetcher {
 			"Number of jobs found per company",
 			[]string{"company"},
 		),
+		pagesFetched: metricsManager.CreateCounterVec(
+			"fetcher_pages_fetched_total",
+			"Total number of listing pages fetched",
+			[]string{"company", "fetch_type"},
+		),
+		notModified: metricsManager.CreateCounterVec(
+			"fetcher_not_modified_total",
+			"Total number of fetches whose listing was not modified since the previous one",
+			[]string{"company", "fetch_type"},
+		),
 	}
 
 	return &JobFetcher{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		},
-		companies: make(map[string]CompanyConfig),
-		metrics:   fetcherMetrics,
+		httpClient:     httpclient.GetClient(),
+		companies:      make(map[string]CompanyConfig),
+		metrics:        fetcherMetrics,
+		validators:     httpclient.NewValidatorCache(),
+		authenticators: make(map[string]httpclient.Authenticator),
+		concurrency:    DefaultConcurrency,
+		lastFetch:      make(map[string]time.Time),
+		truncated:      make(map[string]bool),
 	}
 }
 
@@ -77,13 +117,17 @@ func (f *JobFetcher) LoadFromConfig(configPath string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	if config.Concurrency > 0 {
+		f.concurrency = config.Concurrency
+	}
+
 	for companyKey, companyConfig := range config.Companies {
 		if !companyConfig.Enabled {
 			logger.Info(fmt.Sprintf("Company %s is disabled, skipping", companyKey))
 			continue
 		}
 
-		f.companies[companyKey] = companyConfig
+		f.addCompany(companyKey, companyConfig)
 	}
 
 	return nil
@@ -93,59 +137,152 @@ func (f *JobFetcher) RegisterCompany(config CompanyConfig) error {
 	if err := config.Validate(); err != nil {
 		return fmt.Errorf("invalid company config: %w", err)
 	}
-	f.companies[config.Name] = config
+	f.addCompany(config.Name, config)
 	return nil
 }
 
-func (f *JobFetcher) FetchJobs(companyName string) ([]*models.JobReference, error) {
+func (f *JobFetcher) addCompany(companyName string, config CompanyConfig) {
+	f.companies[companyName] = config
+	if config.Auth != nil {
+		f.authenticators[companyName] = config.Auth.Authenticator(f.httpClient)
+	}
+}
+
+func (f *JobFetcher) FetchJobs(ctx context.Context, companyName string) ([]*models.JobReference, error) {
 	config, exists := f.companies[companyName]
 	if !exists {
 		return nil, fmt.Errorf("company %s not registered", companyName)
 	}
 
+	if err := f.acquireSlot(ctx); err != nil {
+		return nil, err
+	}
+	defer f.releaseSlot()
+
 	start := time.Now()
+	previousFetch := f.lastFetchTime(companyName)
 	defer func() {
 		f.metrics.fetchDuration.WithLabelValues(string(companyName), config.FetchType).Set(time.Since(start).Seconds())
 	}()
 
 	f.metrics.fetchTotal.WithLabelValues(string(companyName), config.FetchType).Inc()
 
+	// Listings are requested conditionally, their validators being kept once the fetch succeeded
+	revalidation := httpclient.NewRevalidation(f.validators)
+	ctx = revalidation.Context(ctx)
+	if auth, exists := f.authenticators[companyName]; exists {
+		ctx = httpclient.WithAuth(ctx, auth, config.URL)
+	}
+
 	var jobs []*models.JobReference
+	var stats fetchStats
 	var err error
 
 	switch config.FetchType {
 	case "sitemap":
-		jobs, err = f.fetchFromSitemap(config)
+		jobs, stats, err = f.fetchFromSitemap(ctx, config)
 	case "html":
-		jobs, err = f.fetchFromHTML(config)
+		jobs, stats, err = f.fetchFromHTML(ctx, config)
 	case "api":
-		jobs, err = f.fetchFromAPI(config)
+		jobs, stats, err = f.fetchFromAPI(ctx, config)
+	case "embedded_json":
+		jobs, stats, err = f.fetchFromEmbeddedJSON(ctx, config)
+	case "feed":
+		jobs, stats, err = f.fetchFromFeed(ctx, config)
 	default:
-		err = fmt.Errorf("unsupported fetch type: %s", config.FetchType)
+		if adapter, exists := GetATSAdapter(config.FetchType); exists {
+			jobs, err = adapter.Discover(ctx, f.httpClient, config)
+		} else {
+			err = fmt.Errorf("unsupported fetch type: %s", config.FetchType)
+		}
+	}
+
+	if stats.Pages > 0 {
+		f.metrics.pagesFetched.WithLabelValues(string(companyName), config.FetchType).Add(float64(stats.Pages))
+	}
+
+	if errors.Is(err, httpclient.ErrNotModified) {
+		logger.Info(fmt.Sprintf("Job listing of %s not modified since the previous fetch", companyName))
+		f.metrics.notModified.WithLabelValues(string(companyName), config.FetchType).Inc()
+		f.setLastFetchTime(companyName, start)
+		return nil, err
 	}
 
 	if err != nil {
-		f.metrics.fetchErrors.WithLabelValues(string(companyName), config.FetchType, "fetch_error").Inc()
+		errorType := httpclient.ErrorType(err)
+		if ctx.Err() != nil {
+			errorType = "cancelled"
+		} else if errorType == "" {
+			errorType = "fetch_error"
+		}
+		f.metrics.fetchErrors.WithLabelValues(string(companyName), config.FetchType, errorType).Inc()
 		return nil, err
 	}
 
+	if stats.Truncated {
+		logger.Warn(fmt.Sprintf("Reached max pages (%d) for %s, job listing is truncated", stats.Pages, companyName))
+		f.metrics.fetchErrors.WithLabelValues(string(companyName), config.FetchType, "max_pages_reached").Inc()
+	}
+
+	// Links are stored canonical, so that a posting keeps the same URL across discoveries
+	base, _ := url.Parse(config.URL)
+	for _, job := range jobs {
+		job.URL = CanonicalizeURL(resolveURL(base, job.URL), config.GetStripQueryParams())
+		job.CompanyName = config.Name
+		job.CompanyKey = companyName
+		job.Unchanged = !previousFetch.IsZero() && job.LastModified != nil && job.LastModified.Before(previousFetch)
+	}
+
+	revalidation.Commit()
+	f.setLastFetchTime(companyName, start)
+	f.setTruncated(companyName, stats.Truncated)
 	f.metrics.jobsFound.WithLabelValues(string(companyName)).Set(float64(len(jobs)))
 	return jobs, nil
 }
 
-func (f *JobFetcher) FetchAllJobs() (map[string][]*models.JobReference, error) {
-	results := make(map[string][]*models.JobReference)
+// lastFetchTime returns when the previous successful fetch of a company started
+func (f *JobFetcher) lastFetchTime(companyName string) time.Time {
+	f.lastFetchMu.RLock()
+	defer f.lastFetchMu.RUnlock()
+	return f.lastFetch[companyName]
+}
 
-	for companyName := range f.companies {
-		jobs, err := f.FetchJobs(companyName)
-		if err != nil {
-			fmt.Printf("Error fetching jobs for %s: %v\n", companyName, err)
-			continue
-		}
-		results[companyName] = jobs
+func (f *JobFetcher) setLastFetchTime(companyName string, fetchTime time.Time) {
+	f.lastFetchMu.Lock()
+	defer f.lastFetchMu.Unlock()
+	f.lastFetch[companyName] = fetchTime
+}
+
+// IsTruncated reports whether the previous successful fetch of a company reached max_pages, leaving
+// postings of its listing out of the references returned
+func (f *JobFetcher) IsTruncated(companyName string) bool {
+	f.lastFetchMu.RLock()
+	defer f.lastFetchMu.RUnlock()
+	return f.truncated[companyName]
+}
+
+func (f *JobFetcher) setTruncated(companyName string, truncated bool) {
+	f.lastFetchMu.Lock()
+	defer f.lastFetchMu.Unlock()
+	f.truncated[companyName] = truncated
+}
+
+// acquireSlot waits until fewer companies than the configured concurrency are being fetched
+func (f *JobFetcher) acquireSlot(ctx context.Context) error {
+	f.slotsOnce.Do(func() {
+		f.slots = make(chan struct{}, f.concurrency)
+	})
+
+	select {
+	case f.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	return results, nil
+func (f *JobFetcher) releaseSlot() {
+	<-f.slots
 }
 
 func (f *JobFetcher) GetRegisteredCompanies() []string {
@@ -156,88 +293,140 @@ func (f *JobFetcher) GetRegisteredCompanies() []string {
 	return companies
 }
 
-func (f *JobFetcher) fetchFromSitemap(config CompanyConfig) ([]*models.JobReference, error) {
-	resp, err := f.httpClient.Get(config.URL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-OK status code: %d", resp.StatusCode)
+// GetSchedule returns the discovery schedule of a company, nil when it has none
+func (f *JobFetcher) GetSchedule(companyName string) schedule.Schedule {
+	config, exists := f.companies[companyName]
+	if !exists || config.Schedule == nil {
+		return nil
 	}
+	return config.Schedule.Schedule()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read sitemap: %w", err)
-	}
+func (f *JobFetcher) fetchFromHTML(ctx context.Context, config CompanyConfig) ([]*models.JobReference, fetchStats, error) {
+	return f.fetchPages(ctx, config, func(resp *http.Response) ([]*models.JobReference, pageResult, error) {
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		if err != nil {
+			return nil, pageResult{}, fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		doc.Url = resp.Request.URL
 
-	var sitemap struct {
-		URLs []struct {
-			Loc string `xml:"loc"`
-		} `xml:"url"`
-	}
+		jobs := f.parseHTMLLinks(config, doc)
+		return jobs, pageResult{Header: resp.Header, Doc: doc, Count: len(jobs)}, nil
+	})
+}
 
-	if err := xml.Unmarshal(body, &sitemap); err != nil {
-		return nil, fmt.Errorf("failed to parse sitemap: %w", err)
-	}
+// parseHTMLLinks lists the job links of a page, resolved against the page URL and canonicalized
+// before their ID is extracted
+func (f *JobFetcher) parseHTMLLinks(config CompanyConfig, doc *goquery.Document) []*models.JobReference {
+	base := documentBase(doc)
 
 	var jobs []*models.JobReference
-	for _, entry := range sitemap.URLs {
-		if externalID := f.extractID(entry.Loc, config.GetCompiledPattern()); externalID != "" {
+	doc.Find(config.LinkSelector).Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		jobURL := CanonicalizeURL(resolveURL(base, href), config.GetStripQueryParams())
+		if externalID := f.extractID(jobURL, config.GetCompiledPattern()); externalID != "" {
 			jobs = append(jobs, &models.JobReference{
 				ExternalID: externalID,
-				URL:        entry.Loc,
+				URL:        jobURL,
 			})
 		}
-	}
+	})
 
-	return jobs, nil
+	return jobs
 }
 
-func (f *JobFetcher) fetchFromHTML(config CompanyConfig) ([]*models.JobReference, error) {
-	resp, err := f.httpClient.Get(config.URL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch HTML: %w", err)
-	}
-	defer resp.Body.Close()
+func (f *JobFetcher) fetchFromAPI(ctx context.Context, config CompanyConfig) ([]*models.JobReference, fetchStats, error) {
+	return f.fetchPages(ctx, config, func(resp *http.Response) ([]*models.JobReference, pageResult, error) {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, pageResult{}, fmt.Errorf("failed to read API response: %w", err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-OK status code: %d", resp.StatusCode)
-	}
+		var data interface{}
+		if err := json.Unmarshal(respBody, &data); err != nil {
+			return nil, pageResult{}, fmt.Errorf("failed to parse API response: %w", err)
+		}
+
+		jobs, err := f.parseAPIResponse(config, data)
+		if err != nil {
+			return nil, pageResult{}, err
+		}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+		return jobs, pageResult{Header: resp.Header, Data: data, Count: len(jobs)}, nil
+	})
+}
+
+// fetchPages requests the pages of a listing until the paginator is exhausted, a page lists no new
+// job (the source ignoring the pagination parameters) or max_pages is reached
+func (f *JobFetcher) fetchPages(ctx context.Context, config CompanyConfig, parse func(resp *http.Response) ([]*models.JobReference, pageResult, error)) ([]*models.JobReference, fetchStats, error) {
+	var stats fetchStats
+
+	pager := newPaginator(config)
+	req, err := pager.first()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, stats, err
 	}
 
 	var jobs []*models.JobReference
-	doc.Find(config.LinkSelector).Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
+	seen := make(map[string]bool)
+	for {
+		pageJobs, result, err := f.fetchPage(ctx, config, req, parse)
+		if err != nil {
+			return nil, stats, err
 		}
+		stats.Pages++
 
-		if externalID := f.extractID(href, config.GetCompiledPattern()); externalID != "" {
-			jobs = append(jobs, &models.JobReference{
-				ExternalID: externalID,
-				URL:        href,
-			})
+		newJobs := 0
+		for _, job := range pageJobs {
+			if seen[job.ExternalID] {
+				continue
+			}
+			seen[job.ExternalID] = true
+			jobs = append(jobs, job)
+			newJobs++
+		}
+		if newJobs == 0 {
+			break
 		}
-	})
 
-	return jobs, nil
+		next, more, err := pager.next(result)
+		if err != nil {
+			return nil, stats, err
+		}
+		if !more {
+			break
+		}
+		if stats.Pages >= pager.maxPages() {
+			stats.Truncated = config.Pagination != nil
+			break
+		}
+		req = next
+		// Only the first page tells whether the listing changed, the following ones are needed in full
+		ctx = httpclient.Unconditional(ctx)
+	}
+
+	return jobs, stats, nil
 }
 
-func (f *JobFetcher) fetchFromAPI(config CompanyConfig) ([]*models.JobReference, error) {
+// fetchPage requests a single listing page and parses it
+func (f *JobFetcher) fetchPage(ctx context.Context, config CompanyConfig, page pageRequest, parse func(resp *http.Response) ([]*models.JobReference, pageResult, error)) ([]*models.JobReference, pageResult, error) {
+	method := config.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
 	var body io.Reader
-	if config.RequestBody != "" {
-		body = strings.NewReader(config.RequestBody)
+	if page.Body != "" {
+		body = strings.NewReader(page.Body)
 	}
 
-	req, err := http.NewRequest(config.Method, config.URL, body)
+	req, err := http.NewRequestWithContext(ctx, method, page.URL, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, pageResult{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	for key, value := range config.Headers {
@@ -246,41 +435,27 @@ func (f *JobFetcher) fetchFromAPI(config CompanyConfig) ([]*models.JobReference,
 
 	resp, err := f.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+		return nil, pageResult{}, fmt.Errorf("failed to fetch %s: %w", page.URL, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-OK status code: %d", resp.StatusCode)
-	}
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read API response: %w", err)
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, pageResult{}, err
 	}
 
-	return f.parseAPIResponse(config, respBody)
+	return parse(resp)
 }
 
-func (f *JobFetcher) parseAPIResponse(config CompanyConfig, respBody []byte) ([]*models.JobReference, error) {
-	var data interface{}
-	if err := json.Unmarshal(respBody, &data); err != nil {
-		return nil, fmt.Errorf("failed to parse API response: %w", err)
-	}
-
+func (f *JobFetcher) parseAPIResponse(config CompanyConfig, data interface{}) ([]*models.JobReference, error) {
 	// Navigate to jobs array using the configured path
 	var jobsArray []interface{}
 
 	if config.JobsPath != "" {
-		jobsData, err := f.getNestedValue(data, config.JobsPath)
+		var err error
+		jobsArray, err = jsonquery.SearchArray(config.JobsPath, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to find jobs at path %s: %w", config.JobsPath, err)
 		}
-		var ok bool
-		jobsArray, ok = jobsData.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("jobs data at path %s is not an array", config.JobsPath)
-		}
 	} else {
 		// If no path specified, assume the response is directly an array
 		var ok bool
@@ -290,69 +465,54 @@ func (f *JobFetcher) parseAPIResponse(config CompanyConfig, respBody []byte) ([]
 		}
 	}
 
-	var jobs []*models.JobReference
-	idField := config.IDField
-	if idField == "" {
-		idField = "id" // default field name
+	idPath := config.IDPath
+	if idPath == "" {
+		idField := config.IDField
+		if idField == "" {
+			idField = "id" // default field name
+		}
+		idPath = quoteField(idField)
 	}
 
+	var jobs []*models.JobReference
 	for _, jobData := range jobsArray {
-		jobMap, ok := jobData.(map[string]interface{})
-		if !ok {
+		if _, ok := jobData.(map[string]interface{}); !ok {
 			continue
 		}
 
-		idValue, exists := jobMap[idField]
-		if !exists {
-			continue
+		externalID, err := jsonquery.SearchString(idPath, jobData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job ID: %w", err)
 		}
-
-		var externalID string
-		switch v := idValue.(type) {
-		case string:
-			externalID = v
-		case float64:
-			externalID = strconv.Itoa(int(v))
-		case int:
-			externalID = strconv.Itoa(v)
-		default:
+		if externalID == "" {
 			continue
 		}
 
-		jobURL := f.generateJobURL(config, externalID)
+		// Paths left empty select nothing, and values missing from a job are left empty
+		jobURL, _ := jsonquery.SearchString(config.URLPath, jobData)
+		if jobURL == "" {
+			jobURL = f.generateJobURL(config, externalID)
+		}
+		title, _ := jsonquery.SearchString(config.TitlePath, jobData)
+		location, _ := jsonquery.SearchString(config.LocationPath, jobData)
+		description, _ := jsonquery.SearchString(config.DescriptionPath, jobData)
 
 		jobs = append(jobs, &models.JobReference{
-			ExternalID: externalID,
-			URL:        jobURL,
+			ExternalID:  externalID,
+			URL:         jobURL,
+			Title:       title,
+			Location:    location,
+			Description: description,
 		})
 	}
 
 	return jobs, nil
 }
 
-// getNestedValue navigates through nested maps using dot notation (e.g., "data.jobs")
-func (f *JobFetcher) getNestedValue(data interface{}, path string) (interface{}, error) {
-	if path == "" {
-		return data, nil
-	}
-
-	parts := strings.Split(path, ".")
-	current := data
-
-	for _, part := range parts {
-		switch v := current.(type) {
-		case map[string]interface{}:
-			var exists bool
-			current, exists = v[part]
-			if !exists {
-				return nil, fmt.Errorf("key '%s' not found", part)
-			}
-		default:
-			return nil, fmt.Errorf("cannot navigate to '%s' in non-object", part)
-		}
-	}
-
-	return current, nil
+// quoteField turns a plain field name into a JMESPath expression selecting it, so that
+// id_field keeps accepting any key, including ones with dashes or dots
+func quoteField(field string) string {
+	return strconv.Quote(field)
 }
 
 func (f *JobFetcher) generateJobURL(config CompanyConfig, externalID string) string {
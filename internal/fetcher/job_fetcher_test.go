@@ -1,9 +1,32 @@
 package fetcher
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/models"
 )
 
+// TestMain disables the rate limits and robots.txt lookups of the shared HTTP client, the test servers
+// being local and answering every path
+func TestMain(m *testing.M) {
+	os.Setenv("HTTP_HOST_RATE_LIMIT", "0")
+	os.Setenv("HTTP_RESPECT_ROBOTS", "false")
+	os.Exit(m.Run())
+}
+
 func TestJobFetcher_RegisterCompany(t *testing.T) {
 	fetcher := NewJobFetcher()
 
@@ -50,7 +73,7 @@ func TestJobFetcher_RegisterCompany_Invalid(t *testing.T) {
 func TestJobFetcher_FetchJobs_UnregisteredCompany(t *testing.T) {
 	fetcher := NewJobFetcher()
 
-	_, err := fetcher.FetchJobs("nonexistent")
+	_, err := fetcher.FetchJobs(context.Background(), "nonexistent")
 	if err == nil {
 		t.Fatal("Expected error for unregistered company, got nil")
 	}
@@ -111,6 +134,88 @@ func TestCompanyConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid greenhouse config",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "greenhouse",
+				Board:     "test",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid lever config",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "lever",
+				Board:     "test",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid feed config",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "feed",
+				URL:       "https://example.com/jobs.rss",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid oauth2 auth",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "api",
+				URL:       "https://api.example.com/jobs",
+				Method:    "GET",
+				Auth: &AuthConfig{
+					Type:         "oauth2_client_credentials",
+					TokenURL:     "https://auth.example.com/oauth/token",
+					ClientID:     "client",
+					ClientSecret: "secret",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "bearer auth missing token",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "api",
+				URL:       "https://api.example.com/jobs",
+				Method:    "GET",
+				Auth:      &AuthConfig{Type: "bearer"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid cron schedule",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "greenhouse",
+				Board:     "test",
+				Schedule:  &ScheduleConfig{Cron: "0 */6 * * *", Jitter: 10 * time.Minute},
+			},
+			wantErr: false,
+		},
+		{
+			name: "schedule with cron and interval",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "greenhouse",
+				Board:     "test",
+				Schedule:  &ScheduleConfig{Cron: "@daily", Interval: time.Hour},
+			},
+			wantErr: true,
+		},
+		{
+			name: "greenhouse missing board",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "greenhouse",
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing name",
 			config: CompanyConfig{
@@ -154,6 +259,17 @@ func TestCompanyConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "api invalid jobs_path",
+			config: CompanyConfig{
+				Name:      "test",
+				FetchType: "api",
+				URL:       "https://test.com/api",
+				Method:    "GET",
+				JobsPath:  "departments[*.jobs",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -165,3 +281,254 @@ func TestCompanyConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestJobFetcher_FetchJobs_Greenhouse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/boards/test/jobs" {
+			t.Errorf("Unexpected request path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"jobs": [
+			{"id": 4001, "title": "Backend Engineer", "absolute_url": "https://job-boards.greenhouse.io/test/jobs/4001"},
+			{"id": 4002, "title": "Data Engineer", "absolute_url": "https://job-boards.greenhouse.io/test/jobs/4002"}
+		]}`))
+	}))
+	defer server.Close()
+
+	fetcher := NewJobFetcher()
+	err := fetcher.RegisterCompany(CompanyConfig{
+		Name:      "test-company",
+		FetchType: "greenhouse",
+		URL:       server.URL + "/v1/boards/test/jobs",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	jobs, err := fetcher.FetchJobs(context.Background(), "test-company")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got: %d", len(jobs))
+	}
+
+	job := jobs[0]
+	if job.ExternalID != "4001" || job.Source != "greenhouse" || job.CompanyName != "test-company" {
+		t.Errorf("Unexpected job reference: %+v", job)
+	}
+
+	if want := server.URL + "/v1/boards/test/jobs/4001"; job.APIURL != want {
+		t.Errorf("Expected API URL %s, got: %s", want, job.APIURL)
+	}
+}
+
+func TestJobFetcher_FetchJobs_Concurrency(t *testing.T) {
+	const concurrency = 2
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintf(w, `<rss><channel><item><title>Engineer</title><link>https://careers.acme.com%s/101</link></item></channel></rss>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	fetcher := NewJobFetcher()
+	fetcher.concurrency = concurrency
+	for i := 0; i < 6; i++ {
+		err := fetcher.RegisterCompany(CompanyConfig{
+			Name:      fmt.Sprintf("company-%d", i),
+			FetchType: "feed",
+			URL:       fmt.Sprintf("%s/company-%d/jobs.rss", server.URL, i),
+		})
+		if err != nil {
+			t.Fatalf("Failed to register company: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, companyName := range fetcher.GetRegisteredCompanies() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fetcher.FetchJobs(context.Background(), companyName); err != nil {
+				t.Errorf("FetchJobs(%s) error = %v", companyName, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got != concurrency {
+		t.Errorf("Expected at most %d companies fetched at once, got: %d", concurrency, got)
+	}
+
+	// A fetch waiting for a slot gives up when its context is cancelled
+	for i := 0; i < concurrency; i++ {
+		if err := fetcher.acquireSlot(context.Background()); err != nil {
+			t.Fatalf("acquireSlot() error = %v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := fetcher.FetchJobs(ctx, "company-0"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected FetchJobs to wait for a slot until its context is done, got: %v", err)
+	}
+	for i := 0; i < concurrency; i++ {
+		fetcher.releaseSlot()
+	}
+	if _, err := fetcher.FetchJobs(context.Background(), "company-0"); err != nil {
+		t.Errorf("Expected the released slots to be available, got: %v", err)
+	}
+}
+
+func TestJobFetcher_FetchJobs_NotModified(t *testing.T) {
+	tests := []struct {
+		name            string
+		fetchType       string
+		path            string
+		wantNotModified bool
+	}{
+		{name: "feed", fetchType: "feed", path: "/jobs.rss", wantNotModified: true},
+		{name: "sitemap", fetchType: "sitemap", path: "/sitemap_jobs.xml", wantNotModified: true},
+		{name: "sitemap index", fetchType: "sitemap", path: "/sitemap_index.xml", wantNotModified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			documents := map[string]string{
+				"/jobs.rss":          `<rss><channel><item><title>Backend Engineer</title><link>https://careers.acme.com/jobs/101</link></item></channel></rss>`,
+				"/sitemap_jobs.xml":  `<urlset><url><loc>https://careers.acme.com/jobs/101</loc></url></urlset>`,
+				"/sitemap_index.xml": `<sitemapindex><sitemap><loc>{{server}}/sitemap_jobs.xml</loc></sitemap></sitemapindex>`,
+			}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				etag := `"` + r.URL.Path + `"`
+				if r.Header.Get("If-None-Match") == etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", etag)
+				fmt.Fprint(w, strings.ReplaceAll(documents[r.URL.Path], "{{server}}", server.URL))
+			}))
+			defer server.Close()
+
+			fetcher := NewJobFetcher()
+			if err := fetcher.RegisterCompany(CompanyConfig{Name: "Acme", FetchType: tt.fetchType, URL: server.URL + tt.path, compiledPattern: regexp.MustCompile(`/jobs/(\d+)`)}); err != nil {
+				t.Fatalf("Failed to register company: %v", err)
+			}
+
+			jobs, err := fetcher.FetchJobs(context.Background(), "Acme")
+			if err != nil || len(jobs) != 1 {
+				t.Fatalf("Expected 1 job on the first fetch, got: %d (error: %v)", len(jobs), err)
+			}
+
+			jobs, err = fetcher.FetchJobs(context.Background(), "Acme")
+			if tt.wantNotModified {
+				if !errors.Is(err, httpclient.ErrNotModified) {
+					t.Errorf("Expected the listing not to be modified, got: %d jobs (error: %v)", len(jobs), err)
+				}
+				return
+			}
+			if err != nil || len(jobs) != 1 {
+				t.Errorf("Expected 1 job on the second fetch, got: %d (error: %v)", len(jobs), err)
+			}
+		})
+	}
+}
+
+func TestJobFetcher_parseAPIResponse(t *testing.T) {
+	response := `{
+		"data": {"jobs": [{"id": 1, "job-id": "a-1"}, {"id": 2, "job-id": "a-2"}]},
+		"departments": [
+			{"name": "Engineering", "jobs": [
+				{"ref": {"id": "eng-1"}, "title": "Backend Engineer", "content": "<p>Build APIs.</p>", "offices": [{"city": "Paris"}], "links": {"apply": "https://acme.com/apply/eng-1"}},
+				{"ref": {"id": "eng-2"}, "title": "Frontend Engineer", "offices": [{"city": "Lyon"}]}
+			]},
+			{"name": "Sales", "jobs": [
+				{"ref": {"id": "sal-1"}, "title": "Account Executive", "offices": []}
+			]}
+		]
+	}`
+
+	tests := []struct {
+		name      string
+		config    CompanyConfig
+		wantIDs   string
+		wantFirst models.JobReference
+	}{
+		{
+			name:      "dot path and id_field",
+			config:    CompanyConfig{JobsPath: "data.jobs", IDField: "id", URLTemplate: "https://acme.com/jobs/{id}"},
+			wantIDs:   "[1 2]",
+			wantFirst: models.JobReference{ExternalID: "1", URL: "https://acme.com/jobs/1"},
+		},
+		{
+			name:      "id_field with a dash",
+			config:    CompanyConfig{JobsPath: "data.jobs", IDField: "job-id", URLTemplate: "https://acme.com/jobs/{id}"},
+			wantIDs:   "[a-1 a-2]",
+			wantFirst: models.JobReference{ExternalID: "a-1", URL: "https://acme.com/jobs/a-1"},
+		},
+		{
+			name: "jobs nested in departments",
+			config: CompanyConfig{
+				JobsPath:        "departments[*].jobs[*]",
+				IDPath:          "ref.id",
+				URLPath:         "links.apply",
+				TitlePath:       "title",
+				LocationPath:    "offices[0].city",
+				DescriptionPath: "content",
+				URLTemplate:     "https://acme.com/jobs/{id}",
+			},
+			wantIDs: "[eng-1 eng-2 sal-1]",
+			wantFirst: models.JobReference{
+				ExternalID:  "eng-1",
+				URL:         "https://acme.com/apply/eng-1",
+				Title:       "Backend Engineer",
+				Location:    "Paris",
+				Description: "<p>Build APIs.</p>",
+			},
+		},
+		{
+			name:      "filter expression",
+			config:    CompanyConfig{JobsPath: "departments[?name=='Sales'].jobs[]", IDPath: "ref.id", URLTemplate: "https://acme.com/jobs/{id}"},
+			wantIDs:   "[sal-1]",
+			wantFirst: models.JobReference{ExternalID: "sal-1", URL: "https://acme.com/jobs/sal-1"},
+		},
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(response), &data); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	fetcher := NewJobFetcher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := fetcher.parseAPIResponse(tt.config, data)
+			if err != nil {
+				t.Fatalf("parseAPIResponse() error = %v", err)
+			}
+
+			var ids []string
+			for _, job := range jobs {
+				ids = append(ids, job.ExternalID)
+			}
+			if fmt.Sprint(ids) != tt.wantIDs {
+				t.Fatalf("Expected jobs %s, got: %v", tt.wantIDs, ids)
+			}
+
+			if *jobs[0] != tt.wantFirst {
+				t.Errorf("parseAPIResponse()[0] = %+v, want %+v", *jobs[0], tt.wantFirst)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gkettani/bobber-the-swe/internal/jsonquery"
+)
+
+// defaultMaxPages caps the number of pages fetched per discovery when max_pages is not set
+const defaultMaxPages = 50
+
+// PaginationConfig describes how to walk through the pages of an API, HTML or embedded JSON listing
+type PaginationConfig struct {
+	Type         string `yaml:"type"`                    // offset, page, cursor, link_header or next_selector
+	Param        string `yaml:"param,omitempty"`         // Query parameter carrying the offset, page number or cursor
+	LimitParam   string `yaml:"limit_param,omitempty"`   // Query parameter carrying the page size
+	Limit        int    `yaml:"limit,omitempty"`         // Page size, a shorter page ends the listing
+	StartPage    *int   `yaml:"start_page,omitempty"`    // First page number, for page pagination (defaults to 1)
+	CursorPath   string `yaml:"cursor_path,omitempty"`   // JMESPath expression of the next cursor or next page URL
+	NextSelector string `yaml:"next_selector,omitempty"` // CSS selector of the next page link
+	MaxPages     int    `yaml:"max_pages,omitempty"`     // Safety cap on the number of pages fetched
+}
+
+// Validate checks that the pagination can be applied to the given fetch type
+func (p *PaginationConfig) Validate(fetchType string) error {
+	if fetchType != "api" && fetchType != "html" && fetchType != "embedded_json" {
+		return fmt.Errorf("pagination is not supported for %s fetch type", fetchType)
+	}
+
+	switch p.Type {
+	case "offset", "page", "link_header":
+	case "cursor":
+		if fetchType == "html" {
+			return fmt.Errorf("cursor pagination is not supported for HTML fetch type")
+		}
+		if p.CursorPath == "" {
+			return fmt.Errorf("cursor_path is required for cursor pagination")
+		}
+		if err := jsonquery.Validate(p.CursorPath); err != nil {
+			return fmt.Errorf("invalid cursor_path: %w", err)
+		}
+	case "next_selector":
+		if fetchType == "api" {
+			return fmt.Errorf("next_selector pagination is not supported for API fetch type")
+		}
+		if p.NextSelector == "" {
+			return fmt.Errorf("next_selector is required for next_selector pagination")
+		}
+	default:
+		return fmt.Errorf("invalid pagination type: %s", p.Type)
+	}
+
+	if p.Limit < 0 || p.MaxPages < 0 {
+		return fmt.Errorf("pagination limit and max_pages must be positive")
+	}
+
+	return nil
+}
+
+// pageRequest is the URL and body of a single page request
+type pageRequest struct {
+	URL  string
+	Body string
+}
+
+// pageResult holds what the paginator needs from a fetched page to request the next one
+type pageResult struct {
+	Header http.Header
+	Data   interface{}       // Decoded JSON response, for API listings
+	Doc    *goquery.Document // Parsed document, for HTML listings
+	Count  int               // Number of jobs listed on the page, before deduplication
+}
+
+// paginator builds the successive page requests of a listing. Offsets, page numbers and cursors
+// are sent as query parameters, or substituted for the {offset}, {page}, {cursor} and {limit}
+// placeholders when the URL or the request body uses them
+type paginator struct {
+	config   *PaginationConfig
+	baseURL  string
+	baseBody string
+	offset   int
+	page     int
+	current  pageRequest
+}
+
+func newPaginator(config CompanyConfig) *paginator {
+	p := &paginator{
+		config:   config.Pagination,
+		baseURL:  config.URL,
+		baseBody: config.RequestBody,
+	}
+	if p.config != nil && p.config.Type == "page" {
+		p.page = p.startPage()
+	}
+	return p
+}
+
+// maxPages returns the number of pages after which the listing is considered truncated
+func (p *paginator) maxPages() int {
+	if p.config == nil {
+		return 1
+	}
+	if p.config.MaxPages > 0 {
+		return p.config.MaxPages
+	}
+	return defaultMaxPages
+}
+
+func (p *paginator) startPage() int {
+	if p.config.StartPage != nil {
+		return *p.config.StartPage
+	}
+	return 1
+}
+
+// first returns the request of the first page
+func (p *paginator) first() (pageRequest, error) {
+	if p.config == nil {
+		p.current = pageRequest{URL: p.baseURL, Body: p.baseBody}
+		return p.current, nil
+	}
+
+	switch p.config.Type {
+	case "offset":
+		return p.build(strconv.Itoa(p.offset))
+	case "page":
+		return p.build(strconv.Itoa(p.page))
+	case "cursor":
+		return p.build("")
+	default:
+		p.current = pageRequest{URL: p.baseURL, Body: p.baseBody}
+		return p.current, nil
+	}
+}
+
+// next returns the request of the page following the given result, or false when the listing is exhausted
+func (p *paginator) next(result pageResult) (pageRequest, bool, error) {
+	if p.config == nil || result.Count == 0 {
+		return pageRequest{}, false, nil
+	}
+
+	switch p.config.Type {
+	case "offset", "page":
+		if p.config.Limit > 0 && result.Count < p.config.Limit {
+			return pageRequest{}, false, nil
+		}
+		if p.config.Type == "offset" {
+			if p.config.Limit > 0 {
+				p.offset += p.config.Limit
+			} else {
+				p.offset += result.Count
+			}
+			req, err := p.build(strconv.Itoa(p.offset))
+			return req, err == nil, err
+		}
+		p.page++
+		req, err := p.build(strconv.Itoa(p.page))
+		return req, err == nil, err
+
+	case "cursor":
+		cursor, err := jsonquery.SearchString(p.config.CursorPath, result.Data)
+		if err != nil || cursor == "" {
+			return pageRequest{}, false, nil
+		}
+
+		// Some APIs return the URL of the next page rather than an opaque cursor
+		if strings.HasPrefix(cursor, "http://") || strings.HasPrefix(cursor, "https://") || strings.HasPrefix(cursor, "/") {
+			return p.follow(cursor)
+		}
+
+		req, err := p.build(cursor)
+		return req, err == nil, err
+
+	case "link_header":
+		nextURL := nextLinkFromHeader(result.Header)
+		if nextURL == "" {
+			return pageRequest{}, false, nil
+		}
+		return p.follow(nextURL)
+
+	case "next_selector":
+		if result.Doc == nil {
+			return pageRequest{}, false, nil
+		}
+		href, exists := result.Doc.Find(p.config.NextSelector).First().Attr("href")
+		if !exists || strings.TrimSpace(href) == "" || strings.HasPrefix(href, "#") {
+			return pageRequest{}, false, nil
+		}
+		return p.follow(resolveURL(documentBase(result.Doc), href))
+	}
+
+	return pageRequest{}, false, nil
+}
+
+// build substitutes the pagination value in the base URL and body
+func (p *paginator) build(value string) (pageRequest, error) {
+	placeholder := "{" + p.config.Type + "}"
+	limit := strconv.Itoa(p.config.Limit)
+
+	req := pageRequest{
+		URL:  strings.NewReplacer(placeholder, url.QueryEscape(value), "{limit}", limit).Replace(p.baseURL),
+		Body: strings.NewReplacer(placeholder, value, "{limit}", limit).Replace(p.baseBody),
+	}
+
+	if !strings.Contains(p.baseURL, placeholder) && !strings.Contains(p.baseBody, placeholder) {
+		params := make(map[string]string)
+		if value != "" {
+			params[p.param()] = value
+		}
+		if p.config.LimitParam != "" && p.config.Limit > 0 {
+			params[p.config.LimitParam] = limit
+		}
+
+		pageURL, err := withQueryParams(req.URL, params)
+		if err != nil {
+			return pageRequest{}, fmt.Errorf("invalid pagination URL: %w", err)
+		}
+		req.URL = pageURL
+	}
+
+	p.current = req
+	return req, nil
+}
+
+// follow requests a next page URL, resolved against the current page
+func (p *paginator) follow(nextURL string) (pageRequest, bool, error) {
+	base, err := url.Parse(p.current.URL)
+	if err != nil {
+		return pageRequest{}, false, fmt.Errorf("invalid page URL: %w", err)
+	}
+	ref, err := url.Parse(nextURL)
+	if err != nil {
+		return pageRequest{}, false, fmt.Errorf("invalid next page URL: %w", err)
+	}
+
+	resolved := base.ResolveReference(ref).String()
+	if resolved == p.current.URL {
+		return pageRequest{}, false, nil
+	}
+
+	p.current = pageRequest{URL: resolved, Body: p.baseBody}
+	return p.current, true, nil
+}
+
+// param returns the query parameter carrying the pagination value
+func (p *paginator) param() string {
+	if p.config.Param != "" {
+		return p.config.Param
+	}
+	return p.config.Type
+}
+
+// nextLinkFromHeader returns the rel="next" target of a Link header (RFC 8288)
+func nextLinkFromHeader(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				key, val, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,351 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// paginatedJobs is the listing served by newPaginatedServer, 7 jobs served 3 per page
+const paginatedJobs = 7
+
+func newPaginatedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	pageOf := func(start int) []int {
+		var ids []int
+		for id := start; id < paginatedJobs && id < start+3; id++ {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	writeJSON := func(w http.ResponseWriter, ids []int, next string) {
+		items := make([]string, 0, len(ids))
+		for _, id := range ids {
+			items = append(items, fmt.Sprintf(`{"id": %d}`, id))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data": {"jobs": [%s]}, "meta": {"next": %q}}`, strings.Join(items, ","), next)
+	}
+
+	writeHTML := func(w http.ResponseWriter, ids []int, next string) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><body><ul>")
+		for _, id := range ids {
+			fmt.Fprintf(w, `<li><a class="job" href="https://acme.com/jobs/%d">Job</a></li>`, id)
+		}
+		fmt.Fprint(w, "</ul>")
+		if next != "" {
+			fmt.Fprintf(w, `<a class="next" href="%s">Next</a>`, next)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/offset", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		writeJSON(w, pageOf(offset), "")
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		writeJSON(w, pageOf((page-1)*3), "")
+	})
+	mux.HandleFunc("/cursor", func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("cursor"), "c"))
+		next := ""
+		if start+3 < paginatedJobs {
+			next = fmt.Sprintf("c%d", start+3)
+		}
+		writeJSON(w, pageOf(start), next)
+	})
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		if start+3 < paginatedJobs {
+			w.Header().Set("Link", fmt.Sprintf(`</link?from=0>; rel="first", </link?from=%d>; rel="next"`, start+3))
+		}
+		writeJSON(w, pageOf(start), "")
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("from"))
+		next := ""
+		if start+3 < paginatedJobs {
+			next = fmt.Sprintf("?from=%d", start+3)
+		}
+		writeHTML(w, pageOf(start), next)
+	})
+	mux.HandleFunc("/ignored", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, pageOf(0), "")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJobFetcher_FetchJobs_Pagination(t *testing.T) {
+	server := newPaginatedServer(t)
+
+	apiConfig := func(path string, pagination *PaginationConfig) CompanyConfig {
+		return CompanyConfig{
+			FetchType:   "api",
+			URL:         server.URL + path,
+			Method:      "GET",
+			JobsPath:    "data.jobs",
+			URLTemplate: "https://acme.com/jobs/{id}",
+			Pagination:  pagination,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		config    CompanyConfig
+		wantJobs  int
+		wantPages int
+		wantTrunc bool
+	}{
+		{
+			name:      "no pagination",
+			config:    apiConfig("/offset", nil),
+			wantJobs:  3,
+			wantPages: 1,
+		},
+		{
+			name:      "offset",
+			config:    apiConfig("/offset", &PaginationConfig{Type: "offset", Param: "start", LimitParam: "limit", Limit: 3}),
+			wantJobs:  paginatedJobs,
+			wantPages: 3,
+		},
+		{
+			name:      "offset without limit",
+			config:    apiConfig("/offset", &PaginationConfig{Type: "offset", Param: "start"}),
+			wantJobs:  paginatedJobs,
+			wantPages: 4,
+		},
+		{
+			name:      "page number",
+			config:    apiConfig("/page", &PaginationConfig{Type: "page", Param: "p", Limit: 3}),
+			wantJobs:  paginatedJobs,
+			wantPages: 3,
+		},
+		{
+			name:      "page placeholder",
+			config:    apiConfig("/page?p={page}", &PaginationConfig{Type: "page", Limit: 3}),
+			wantJobs:  paginatedJobs,
+			wantPages: 3,
+		},
+		{
+			name:      "cursor",
+			config:    apiConfig("/cursor", &PaginationConfig{Type: "cursor", CursorPath: "meta.next"}),
+			wantJobs:  paginatedJobs,
+			wantPages: 3,
+		},
+		{
+			name:      "link header",
+			config:    apiConfig("/link", &PaginationConfig{Type: "link_header"}),
+			wantJobs:  paginatedJobs,
+			wantPages: 3,
+		},
+		{
+			name: "next selector",
+			config: CompanyConfig{
+				FetchType:    "html",
+				URL:          server.URL + "/html",
+				LinkSelector: "a.job",
+				IDPattern:    `/jobs/(\d+)`,
+				Pagination:   &PaginationConfig{Type: "next_selector", NextSelector: "a.next"},
+			},
+			wantJobs:  paginatedJobs,
+			wantPages: 3,
+		},
+		{
+			name:      "max pages",
+			config:    apiConfig("/cursor", &PaginationConfig{Type: "cursor", CursorPath: "meta.next", MaxPages: 2}),
+			wantJobs:  6,
+			wantPages: 2,
+			wantTrunc: true,
+		},
+		{
+			name:      "pagination ignored by the source",
+			config:    apiConfig("/ignored", &PaginationConfig{Type: "offset", Param: "start"}),
+			wantJobs:  3,
+			wantPages: 2,
+		},
+	}
+
+	fetcher := NewJobFetcher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Name = "Acme"
+			if err := tt.config.Validate(); err != nil {
+				t.Fatalf("Expected valid config, got: %v", err)
+			}
+			if tt.config.IDPattern != "" {
+				tt.config.compiledPattern = regexp.MustCompile(tt.config.IDPattern)
+			}
+
+			var (
+				jobs  []*models.JobReference
+				stats fetchStats
+				err   error
+			)
+			if tt.config.FetchType == "html" {
+				jobs, stats, err = fetcher.fetchFromHTML(context.Background(), tt.config)
+			} else {
+				jobs, stats, err = fetcher.fetchFromAPI(context.Background(), tt.config)
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(jobs) != tt.wantJobs {
+				t.Errorf("Expected %d jobs, got: %d", tt.wantJobs, len(jobs))
+			}
+			if stats.Pages != tt.wantPages {
+				t.Errorf("Expected %d pages, got: %d", tt.wantPages, stats.Pages)
+			}
+			if stats.Truncated != tt.wantTrunc {
+				t.Errorf("Expected truncated = %v, got: %v", tt.wantTrunc, stats.Truncated)
+			}
+		})
+	}
+}
+
+func TestPaginationConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		fetchType  string
+		pagination PaginationConfig
+		wantErr    bool
+	}{
+		{
+			name:       "offset on api",
+			fetchType:  "api",
+			pagination: PaginationConfig{Type: "offset", Limit: 50},
+		},
+		{
+			name:       "next selector on html",
+			fetchType:  "html",
+			pagination: PaginationConfig{Type: "next_selector", NextSelector: "a.next"},
+		},
+		{
+			name:       "cursor without path",
+			fetchType:  "api",
+			pagination: PaginationConfig{Type: "cursor"},
+			wantErr:    true,
+		},
+		{
+			name:       "next selector on api",
+			fetchType:  "api",
+			pagination: PaginationConfig{Type: "next_selector", NextSelector: "a.next"},
+			wantErr:    true,
+		},
+		{
+			name:       "sitemap",
+			fetchType:  "sitemap",
+			pagination: PaginationConfig{Type: "page"},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown type",
+			fetchType:  "api",
+			pagination: PaginationConfig{Type: "scroll"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pagination.Validate(tt.fetchType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextLinkFromHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		link []string
+		want string
+	}{
+		{
+			name: "next among other relations",
+			link: []string{`<https://api.acme.com/jobs?page=1>; rel="prev", <https://api.acme.com/jobs?page=3>; rel="next"`},
+			want: "https://api.acme.com/jobs?page=3",
+		},
+		{
+			name: "multiple relation types",
+			link: []string{`</jobs?page=2>; rel="next last"`},
+			want: "/jobs?page=2",
+		},
+		{
+			name: "last page",
+			link: []string{`<https://api.acme.com/jobs?page=1>; rel="first"`},
+			want: "",
+		},
+		{
+			name: "no header",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, value := range tt.link {
+				header.Add("Link", value)
+			}
+			if got := nextLinkFromHeader(header); got != tt.want {
+				t.Errorf("nextLinkFromHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobFetcher_IsTruncated(t *testing.T) {
+	server := newPaginatedServer(t)
+
+	tests := []struct {
+		name     string
+		maxPages int
+		want     bool
+	}{
+		{name: "complete listing", want: false},
+		{name: "max pages reached", maxPages: 2, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewJobFetcher()
+			err := fetcher.RegisterCompany(CompanyConfig{
+				Name:        "Acme",
+				FetchType:   "api",
+				URL:         server.URL + "/cursor",
+				Method:      "GET",
+				JobsPath:    "data.jobs",
+				URLTemplate: "https://acme.com/jobs/{id}",
+				Pagination:  &PaginationConfig{Type: "cursor", CursorPath: "meta.next", MaxPages: tt.maxPages},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if _, err := fetcher.FetchJobs(context.Background(), "Acme"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := fetcher.IsTruncated("Acme"); got != tt.want {
+				t.Errorf("Expected truncated = %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
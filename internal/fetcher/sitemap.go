@@ -0,0 +1,191 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// maxSitemapDepth bounds the recursion through nested sitemap indexes
+const maxSitemapDepth = 3
+
+// maxSitemapSize is the largest uncompressed sitemap allowed by the sitemaps.org protocol, bounding the
+// memory a decompressed .xml.gz takes
+const maxSitemapSize = 50 * 1024 * 1024
+
+// sitemapDocument is either a <urlset> listing pages or a <sitemapindex> listing child sitemaps
+type sitemapDocument struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapWalk holds the state of a recursive sitemap fetch
+type sitemapWalk struct {
+	config  CompanyConfig
+	visited map[string]bool
+	seen    map[string]bool
+	jobs    []*models.JobReference
+	stats   fetchStats
+}
+
+func (f *JobFetcher) fetchFromSitemap(ctx context.Context, config CompanyConfig) ([]*models.JobReference, fetchStats, error) {
+	walk := &sitemapWalk{
+		config:  config,
+		visited: make(map[string]bool),
+		seen:    make(map[string]bool),
+	}
+
+	if err := f.walkSitemap(ctx, walk, config.URL, 0); err != nil {
+		return nil, walk.stats, err
+	}
+
+	return walk.jobs, walk.stats, nil
+}
+
+// walkSitemap fetches a sitemap, collecting its job URLs or following the child sitemaps of an index
+func (f *JobFetcher) walkSitemap(ctx context.Context, walk *sitemapWalk, sitemapURL string, depth int) error {
+	if walk.visited[sitemapURL] {
+		return nil
+	}
+	walk.visited[sitemapURL] = true
+
+	sitemap, err := f.getSitemap(ctx, sitemapURL)
+	if err != nil {
+		return err
+	}
+	walk.stats.Pages++
+
+	for _, entry := range sitemap.URLs {
+		loc := strings.TrimSpace(entry.Loc)
+		externalID := f.extractID(loc, walk.config.GetCompiledPattern())
+		if externalID == "" || walk.seen[externalID] {
+			continue
+		}
+		walk.seen[externalID] = true
+
+		walk.jobs = append(walk.jobs, &models.JobReference{
+			ExternalID:   externalID,
+			URL:          loc,
+			LastModified: parseLastMod(entry.LastMod),
+		})
+	}
+
+	if len(sitemap.Sitemaps) == 0 {
+		return nil
+	}
+
+	// Child sitemaps can change without their index, so indexes and their children are always requested in full
+	httpclient.Forget(ctx, sitemapURL)
+	ctx = httpclient.Unconditional(ctx)
+
+	if depth >= maxSitemapDepth {
+		logger.Warn(fmt.Sprintf("Sitemap index %s is nested more than %d levels deep, skipping its children", sitemapURL, maxSitemapDepth))
+		return nil
+	}
+
+	pattern := walk.config.GetCompiledSitemapPattern()
+	for _, child := range sitemap.Sitemaps {
+		loc := strings.TrimSpace(child.Loc)
+		if loc == "" || (pattern != nil && !pattern.MatchString(loc)) {
+			continue
+		}
+
+		if err := f.walkSitemap(ctx, walk, loc, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSitemap fetches and parses a single sitemap, decompressing it when gzipped
+func (f *JobFetcher) getSitemap(ctx context.Context, sitemapURL string) (*sitemapDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	// .xml.gz sitemaps are served as gzip files rather than with a gzip Content-Encoding,
+	// so they are recognized by their magic number
+	reader := bufio.NewReader(resp.Body)
+	var body io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sitemap: %w", err)
+	}
+	if len(data) > maxSitemapSize {
+		return nil, fmt.Errorf("sitemap exceeds %d bytes uncompressed", maxSitemapSize)
+	}
+
+	var sitemap sitemapDocument
+	if err := xml.Unmarshal(data, &sitemap); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+
+	return &sitemap, nil
+}
+
+// lastModLayouts are the W3C datetime formats allowed in <lastmod>
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+}
+
+// parseLastMod parses a <lastmod> value, returning nil when it is missing or malformed
+func parseLastMod(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+
+	// A date alone means the posting changed at some point that day, so it is taken as the end of
+	// the day to avoid mistaking postings changed after the previous discovery for unchanged ones
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		endOfDay := t.Add(24*time.Hour - time.Second)
+		return &endOfDay
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package fetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSitemapServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>%[1]s/sitemap_jobs_1.xml.gz</loc><lastmod>2025-03-01</lastmod></sitemap>
+  <sitemap><loc>%[1]s/sitemap_jobs_2.xml</loc></sitemap>
+  <sitemap><loc>%[1]s/sitemap_blog.xml</loc></sitemap>
+  <sitemap><loc>%[1]s/sitemap_index.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+	})
+	mux.HandleFunc("/sitemap_jobs_1.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		fmt.Fprint(gz, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://careers.acme.com/jobs/101/</loc><lastmod>2025-01-10T08:00:00+00:00</lastmod></url>
+  <url><loc>https://careers.acme.com/jobs/102/</loc><lastmod>2099-01-01</lastmod></url>
+</urlset>`)
+		gz.Close()
+		w.Header().Set("Content-Type", "application/x-gzip")
+		w.Write(buf.Bytes())
+	})
+	mux.HandleFunc("/sitemap_jobs_2.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://careers.acme.com/jobs/103/</loc></url>
+  <url><loc>https://careers.acme.com/jobs/101/</loc></url>
+</urlset>`)
+	})
+	mux.HandleFunc("/sitemap_blog.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://careers.acme.com/jobs/999/</loc></url>
+</urlset>`)
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJobFetcher_FetchJobs_SitemapIndex(t *testing.T) {
+	server := newSitemapServer(t)
+
+	config := CompanyConfig{
+		Name:                   "Acme",
+		FetchType:              "sitemap",
+		URL:                    server.URL + "/sitemap_index.xml",
+		compiledPattern:        regexp.MustCompile(`/jobs/(\d+)/`),
+		compiledSitemapPattern: regexp.MustCompile(`sitemap_jobs_`),
+	}
+
+	fetcher := NewJobFetcher()
+	if err := fetcher.RegisterCompany(config); err != nil {
+		t.Fatalf("Failed to register company: %v", err)
+	}
+
+	jobs, err := fetcher.FetchJobs(context.Background(), "Acme")
+	if err != nil {
+		t.Fatalf("FetchJobs() error = %v", err)
+	}
+
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.ExternalID)
+		if job.Unchanged {
+			t.Errorf("Expected job %s not to be unchanged on the first discovery", job.ExternalID)
+		}
+	}
+	if want := "[101 102 103]"; fmt.Sprint(ids) != want {
+		t.Errorf("Expected jobs %s, got: %v", want, ids)
+	}
+
+	if jobs[0].LastModified == nil || !jobs[0].LastModified.Equal(time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected last modification time: %v", jobs[0].LastModified)
+	}
+
+	// The second discovery only reports the postings changed since the first one as changed
+	jobs, err = fetcher.FetchJobs(context.Background(), "Acme")
+	if err != nil {
+		t.Fatalf("FetchJobs() error = %v", err)
+	}
+
+	unchanged := make(map[string]bool)
+	for _, job := range jobs {
+		unchanged[job.ExternalID] = job.Unchanged
+	}
+	if !unchanged["101"] || unchanged["102"] || unchanged["103"] {
+		t.Errorf("Expected only job 101 to be unchanged, got: %v", unchanged)
+	}
+}
+
+func TestParseLastMod(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		isNil bool
+	}{
+		{value: "2025-03-01T10:30:00Z", want: time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "2025-03-01T10:30:00.123+00:00", want: time.Date(2025, 3, 1, 10, 30, 0, 123000000, time.UTC)},
+		{value: "2025-03-01T10:30+00:00", want: time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "2025-03-01", want: time.Date(2025, 3, 1, 23, 59, 59, 0, time.UTC)},
+		{value: "", isNil: true},
+		{value: "yesterday", isNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := parseLastMod(tt.value)
+			if tt.isNil {
+				if got != nil {
+					t.Errorf("parseLastMod() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || !got.Equal(tt.want) {
+				t.Errorf("parseLastMod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobFetcher_GetSitemap_SizeLimit(t *testing.T) {
+	// A small gzip file decompressing past the sitemaps.org limit
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	fmt.Fprint(gz, `<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	gz.Write(bytes.Repeat([]byte(" "), maxSitemapSize))
+	fmt.Fprint(gz, `</urlset>`)
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-gzip")
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	_, err := NewJobFetcher().getSitemap(context.Background(), server.URL+"/sitemap_jobs.xml.gz")
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Expected the sitemap to exceed the size limit, got: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package fetcher
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultStripQueryParams are the tracking parameters removed from job URLs when strip_query_params is not set.
+// A trailing * matches any parameter with the prefix.
+var DefaultStripQueryParams = []string{"utm_*", "gh_src", "gclid", "fbclid"}
+
+// documentBase returns the URL relative links of a page are resolved against: its <base href>
+// when present, resolved itself against the page URL, or the page URL
+func documentBase(doc *goquery.Document) *url.URL {
+	base := doc.Url
+	if href, exists := doc.Find("base[href]").First().Attr("href"); exists {
+		if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			if base == nil {
+				return ref
+			}
+			return base.ResolveReference(ref)
+		}
+	}
+	return base
+}
+
+// resolveURL resolves a possibly relative link against a base URL, links are returned as is without base
+func resolveURL(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if base == nil || href == "" {
+		return href
+	}
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// CanonicalizeURL normalizes a job URL so that the links to a posting found in different places are equal:
+// the scheme and host are lowercased, default ports and fragments are dropped, the given query
+// parameters are removed and the remaining ones are sorted. URLs that cannot be parsed are returned as is.
+func CanonicalizeURL(rawURL string, stripParams []string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	for param := range query {
+		if matchesParam(param, stripParams) {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+func matchesParam(param string, patterns []string) bool {
+	param = strings.ToLower(param)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), param); matched {
+			return true
+		}
+	}
+	return false
+}
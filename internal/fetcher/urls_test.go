@@ -0,0 +1,132 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		stripParams []string
+		want        string
+	}{
+		{
+			name:        "tracking parameters",
+			url:         "https://careers.acme.com/jobs/101?gh_src=abc&utm_source=linkedin&utm_medium=social",
+			stripParams: DefaultStripQueryParams,
+			want:        "https://careers.acme.com/jobs/101",
+		},
+		{
+			name:        "remaining parameters are sorted",
+			url:         "https://careers.acme.com/job?utm_campaign=x&lang=en&id=101",
+			stripParams: DefaultStripQueryParams,
+			want:        "https://careers.acme.com/job?id=101&lang=en",
+		},
+		{
+			name:        "host, default port and fragment",
+			url:         "HTTPS://Careers.Acme.com:443/jobs/101#apply",
+			stripParams: DefaultStripQueryParams,
+			want:        "https://careers.acme.com/jobs/101",
+		},
+		{
+			name:        "custom parameters",
+			url:         "https://careers.acme.com/jobs/101?ref=home&source=board",
+			stripParams: []string{"ref", "source"},
+			want:        "https://careers.acme.com/jobs/101",
+		},
+		{
+			name:        "no stripping",
+			url:         "https://careers.acme.com/jobs/101?utm_source=x",
+			stripParams: []string{},
+			want:        "https://careers.acme.com/jobs/101?utm_source=x",
+		},
+		{
+			name:        "relative URL",
+			url:         "/jobs/101",
+			stripParams: DefaultStripQueryParams,
+			want:        "/jobs/101",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalizeURL(tt.url, tt.stripParams); got != tt.want {
+				t.Errorf("CanonicalizeURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobFetcher_FetchJobs_RelativeLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "root-relative links",
+			page: `<a class="job" href="/jobs/101?gh_src=abc">Backend Engineer</a>`,
+			want: "{{server}}/jobs/101",
+		},
+		{
+			name: "links relative to the page",
+			page: `<a class="job" href="101?utm_source=x">Backend Engineer</a>`,
+			want: "{{server}}/careers/101",
+		},
+		{
+			name: "base element",
+			page: `<head><base href="https://careers.acme.com/en/"></head><a class="job" href="jobs/101">Backend Engineer</a>`,
+			want: "https://careers.acme.com/en/jobs/101",
+		},
+		{
+			name: "absolute links",
+			page: `<a class="job" href="https://careers.acme.com/jobs/101#apply">Backend Engineer</a>`,
+			want: "https://careers.acme.com/jobs/101",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "<html>%s</html>", tt.page)
+			}))
+			defer server.Close()
+
+			config := CompanyConfig{
+				Name:            "Acme",
+				FetchType:       "html",
+				URL:             server.URL + "/careers/",
+				LinkSelector:    "a.job",
+				compiledPattern: regexp.MustCompile(`/(\d+)$`),
+			}
+
+			fetcher := NewJobFetcher()
+			if err := fetcher.RegisterCompany(config); err != nil {
+				t.Fatalf("Failed to register company: %v", err)
+			}
+
+			jobs, err := fetcher.FetchJobs(context.Background(), "Acme")
+			if err != nil {
+				t.Fatalf("FetchJobs() error = %v", err)
+			}
+			if len(jobs) != 1 {
+				t.Fatalf("Expected 1 job, got %d", len(jobs))
+			}
+
+			want := strings.ReplaceAll(tt.want, "{{server}}", server.URL)
+			if jobs[0].URL != want {
+				t.Errorf("Expected URL %q, got: %q", want, jobs[0].URL)
+			}
+			if jobs[0].ExternalID != "101" {
+				t.Errorf("Expected ID 101, got: %q", jobs[0].ExternalID)
+			}
+		})
+	}
+}
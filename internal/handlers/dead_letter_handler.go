@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/services"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService services.DeadLetterService
+}
+
+func NewDeadLetterHandler(deadLetterService services.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// deadLetterActionResult is the number of dead letters affected by a retry or a purge
+type deadLetterActionResult struct {
+	Company  string `json:"company"`
+	Affected int64  `json:"affected"`
+}
+
+// ListDeadLetters handles GET /api/admin/companies/{key}/dead-letters
+func (h *DeadLetterHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	companyKey, ok := h.parseCompanyKey(w, r)
+	if !ok {
+		return
+	}
+
+	deadLetters, err := h.deadLetterService.List(r.Context(), companyKey)
+	if err != nil {
+		logger.LogWithRequestID(r.Context(), "error", "Failed to list dead letters", "error", err, "company", companyKey)
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve dead letters")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, models.NewSuccessResponse(deadLetters))
+}
+
+// RetryDeadLetters handles POST /api/admin/companies/{key}/dead-letters/retry, retrying the dead letter
+// given by the id query parameter or all the dead letters of the company
+func (h *DeadLetterHandler) RetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	companyKey, ok := h.parseCompanyKey(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	affected, err := h.deadLetterService.Retry(r.Context(), companyKey, id)
+	if err != nil {
+		logger.LogWithRequestID(r.Context(), "error", "Failed to retry dead letters", "error", err, "company", companyKey, "id", id)
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retry dead letters")
+		return
+	}
+
+	logger.LogWithRequestID(r.Context(), "info", "Scheduled dead letters for a retry", "company", companyKey, "id", id, "affected", affected)
+	h.writeJSONResponse(w, http.StatusOK, models.NewSuccessResponse(deadLetterActionResult{Company: companyKey, Affected: affected}))
+}
+
+// PurgeDeadLetters handles DELETE /api/admin/companies/{key}/dead-letters, purging the dead letter given
+// by the id query parameter or all the dead letters of the company
+func (h *DeadLetterHandler) PurgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	companyKey, ok := h.parseCompanyKey(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	affected, err := h.deadLetterService.Purge(r.Context(), companyKey, id)
+	if err != nil {
+		logger.LogWithRequestID(r.Context(), "error", "Failed to purge dead letters", "error", err, "company", companyKey, "id", id)
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to purge dead letters")
+		return
+	}
+
+	logger.LogWithRequestID(r.Context(), "info", "Purged dead letters", "company", companyKey, "id", id, "affected", affected)
+	h.writeJSONResponse(w, http.StatusOK, models.NewSuccessResponse(deadLetterActionResult{Company: companyKey, Affected: affected}))
+}
+
+// parseCompanyKey extracts the company of /api/admin/companies/{key}/dead-letters paths, by its key in the
+// companies configuration as companies may share a name
+func (h *DeadLetterHandler) parseCompanyKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 5 || pathParts[3] == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid company key")
+		return "", false
+	}
+	return pathParts[3], true
+}
+
+// parseID parses the optional id query parameter, 0 standing for every dead letter of the company
+func (h *DeadLetterHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		return 0, true
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid dead letter ID format")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeJSONResponse writes a JSON response
+func (h *DeadLetterHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// writeErrorResponse writes an error response
+func (h *DeadLetterHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	h.writeJSONResponse(w, statusCode, models.NewErrorResponse[interface{}](message))
+}
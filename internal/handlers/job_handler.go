@@ -79,6 +79,41 @@ func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, models.NewSuccessResponse(job))
 }
 
+// GetJobHistory handles GET /api/jobs/{id}/history
+func (h *JobHandler) GetJobHistory(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) < 4 {
+		logger.LogWithRequestID(r.Context(), "warn", "Invalid job ID in request path", "path", r.URL.Path)
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	idStr := pathParts[2] // /api/jobs/{id}/history
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.LogWithRequestID(r.Context(), "warn", "Invalid job ID format", "id_string", idStr, "error", err)
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid job ID format")
+		return
+	}
+
+	logger.LogWithRequestID(r.Context(), "info", "Processing job history request", "job_id", id)
+
+	history, err := h.queryService.GetJobHistory(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			logger.LogWithRequestID(r.Context(), "warn", "Job not found", "job_id", id)
+			h.writeErrorResponse(w, http.StatusNotFound, "Job not found")
+			return
+		}
+		logger.LogWithRequestID(r.Context(), "error", "Failed to get job history", "error", err, "job_id", id)
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve job history")
+		return
+	}
+
+	logger.LogWithRequestID(r.Context(), "info", "Successfully retrieved job history", "job_id", id, "changes", len(history.Changes))
+	h.writeJSONResponse(w, http.StatusOK, models.NewSuccessResponse(history))
+}
+
 // SearchJobs handles GET /api/jobs/search
 func (h *JobHandler) SearchJobs(w http.ResponseWriter, r *http.Request) {
 	// Get search query
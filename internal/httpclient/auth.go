@@ -0,0 +1,231 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+// tokenExpiryMargin is how long before their expiry access tokens are refreshed, so that they do not
+// expire while a request is in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// Authenticator adds credentials to requests
+type Authenticator interface {
+	Authorize(ctx context.Context, req *http.Request) error
+
+	// Invalidate drops the cached credentials rejected by the server, returning whether new ones
+	// can be obtained for the request to be retried
+	Invalidate() bool
+}
+
+type authKey struct{}
+
+type authContext struct {
+	auth Authenticator
+	host string
+}
+
+// WithAuth returns a context whose requests to the host of baseURL are authenticated, so that credentials
+// are not sent to other hosts the requests are redirected to
+func WithAuth(ctx context.Context, auth Authenticator, baseURL string) context.Context {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, authKey{}, &authContext{auth: auth, host: parsed.Host})
+}
+
+// withoutAuth returns a context whose requests are not authenticated, for the token requests
+func withoutAuth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authKey{}, (*authContext)(nil))
+}
+
+// authTransport authenticates the requests made with a WithAuth context, retrying once with new
+// credentials when the server rejects cached ones
+type authTransport struct {
+	base http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a, _ := req.Context().Value(authKey{}).(*authContext)
+	if a == nil || req.URL.Host != a.host {
+		return t.base.RoundTrip(req)
+	}
+
+	authorized := req.Clone(req.Context())
+	if err := a.auth.Authorize(req.Context(), authorized); err != nil {
+		return nil, fmt.Errorf("failed to authorize request: %w", err)
+	}
+
+	resp, err := t.base.RoundTrip(authorized)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil || !a.auth.Invalidate() {
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	authorized = req.Clone(req.Context())
+	if req.Body != nil {
+		if authorized.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+	}
+	if err := a.auth.Authorize(req.Context(), authorized); err != nil {
+		return nil, fmt.Errorf("failed to authorize request: %w", err)
+	}
+	return t.base.RoundTrip(authorized)
+}
+
+type bearerAuth struct {
+	token string
+}
+
+// NewBearerAuth authenticates requests with a static bearer token
+func NewBearerAuth(token string) Authenticator {
+	return &bearerAuth{token: token}
+}
+
+func (a *bearerAuth) Authorize(ctx context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+func (a *bearerAuth) Invalidate() bool {
+	return false
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+// NewBasicAuth authenticates requests with HTTP basic authentication
+func NewBasicAuth(username, password string) Authenticator {
+	return &basicAuth{username: username, password: password}
+}
+
+func (a *basicAuth) Authorize(ctx context.Context, req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+func (a *basicAuth) Invalidate() bool {
+	return false
+}
+
+// clientCredentialsAuth authenticates requests with access tokens of the OAuth2 client credentials grant,
+// caching them until shortly before they expire
+type clientCredentialsAuth struct {
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu       sync.Mutex
+	token    string
+	expiry   time.Time // zero when the token does not expire
+	redacted string    // access token redacted from the logs, kept when the token is invalidated
+}
+
+// NewClientCredentialsAuth authenticates requests with access tokens requested from tokenURL with
+// the OAuth2 client credentials grant
+func NewClientCredentialsAuth(client *http.Client, tokenURL, clientID, clientSecret string, scopes []string) Authenticator {
+	return &clientCredentialsAuth{
+		client:       client,
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+func (a *clientCredentialsAuth) Authorize(ctx context.Context, req *http.Request) error {
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (a *clientCredentialsAuth) Invalidate() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+	return true
+}
+
+// accessToken returns the cached access token, requesting a new one when it is about to expire.
+// Concurrent requests wait for the same token request.
+func (a *clientCredentialsAuth) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && (a.expiry.IsZero() || time.Until(a.expiry) > tokenExpiryMargin) {
+		return a.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(withoutAuth(ctx), http.MethodPost, a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The response body is not part of the error as it may echo the credentials
+	if err := CheckResponse(resp); err != nil {
+		return "", fmt.Errorf("failed to request access token: %w", err)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	// Access tokens are redacted from the logs like the credentials they are requested with, the
+	// refreshed one in place of the previous one
+	logger.ReplaceSecret(a.redacted, token.AccessToken)
+	a.redacted = token.AccessToken
+	a.token = token.AccessToken
+	a.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		a.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return a.token, nil
+}
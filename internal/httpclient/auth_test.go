@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+func TestAuthTransport(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var validToken atomic.Value
+	validToken.Store("token-1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			id, secret, _ := r.BasicAuth()
+			if id != "client" || secret != "s3cr3t" || r.FormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := tokenRequests.Add(1)
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token": fmt.Sprintf("token-%d", n),
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+		default:
+			if r.Header.Get("Authorization") != "Bearer "+validToken.Load().(string) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client := New(&Config{UserAgent: "bobber/1.0"})
+	auth := NewClientCredentialsAuth(client, server.URL+"/oauth/token", "client", "s3cr3t", []string{"jobs:read"})
+	ctx := WithAuth(context.Background(), auth, server.URL)
+
+	get := func(ctx context.Context) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/jobs", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for i := 0; i < 3; i++ {
+		if status := get(ctx); status != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", status)
+		}
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Errorf("Expected the access token to be cached, got %d token requests", got)
+	}
+	if got := logger.Redact("Bearer token-1"); got != "Bearer "+logger.Redacted {
+		t.Errorf("Expected the access token to be redacted from the logs, got: %s", got)
+	}
+
+	// A revoked token is refreshed and the request retried
+	validToken.Store("token-2")
+	if status := get(ctx); status != http.StatusOK {
+		t.Errorf("Expected status 200 after refreshing the token, got: %d", status)
+	}
+	if got := tokenRequests.Load(); got != 2 {
+		t.Errorf("Expected the access token to be refreshed once, got %d token requests", got)
+	}
+	if got := logger.Redact("token-1 token-2"); got != "token-1 "+logger.Redacted {
+		t.Errorf("Expected the refreshed access token to be redacted in place of the previous one, got: %s", got)
+	}
+
+	// Requests to other hosts are not authenticated
+	otherHost := WithAuth(context.Background(), auth, "https://example.com")
+	if status := get(otherHost); status != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a request to another host, got: %d", status)
+	}
+}
@@ -0,0 +1,107 @@
+// Package httpclient provides the HTTP client shared by discovery and enrichment, so that the requests
+// both make to a host are throttled together and identify the crawler the same way.
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+type Config struct {
+	UserAgent string `env:"HTTP_USER_AGENT" envDefault:"bobber/1.0 (+https://github.com/gkettani/bobber-the-swe)"`
+
+	// Proxy the requests are sent through, http://, https:// or socks5://, the HTTP_PROXY and HTTPS_PROXY
+	// environment variables being used when it is not set
+	ProxyURL *url.URL `env:"HTTP_PROXY_URL"`
+
+	// Time allowed to each attempt of a request, reading the response included, to connect and to
+	// complete the TLS handshake
+	Timeout             time.Duration `env:"HTTP_TIMEOUT" envDefault:"30s"`
+	DialTimeout         time.Duration `env:"HTTP_DIAL_TIMEOUT" envDefault:"10s"`
+	TLSHandshakeTimeout time.Duration `env:"HTTP_TLS_HANDSHAKE_TIMEOUT" envDefault:"10s"`
+
+	// Retries of requests failing with a transient error, 429 or 5xx gateway status, waiting from the base
+	// delay doubled with each attempt up to the maximum delay
+	MaxRetries     int           `env:"HTTP_MAX_RETRIES" envDefault:"3"`
+	RetryBaseDelay time.Duration `env:"HTTP_RETRY_BASE_DELAY" envDefault:"500ms"`
+	RetryMaxDelay  time.Duration `env:"HTTP_RETRY_MAX_DELAY" envDefault:"30s"`
+
+	// Requests per second allowed to each host, 0 disabling the limit, and the burst of requests sent at once
+	HostRateLimit float64 `env:"HTTP_HOST_RATE_LIMIT" envDefault:"1"`
+	HostBurst     int     `env:"HTTP_HOST_BURST" envDefault:"3"`
+
+	// Whether robots.txt rules and Crawl-delay are honored, and how long a host's robots.txt is cached
+	RespectRobots  bool          `env:"HTTP_RESPECT_ROBOTS" envDefault:"true"`
+	RobotsCacheTTL time.Duration `env:"HTTP_ROBOTS_CACHE_TTL" envDefault:"24h"`
+
+	// Where the ETag and Last-Modified of responses are kept for conditional requests, "memory" or "redis"
+	CacheBackend string `env:"HTTP_CACHE_BACKEND" envDefault:"memory"`
+}
+
+var (
+	instance *http.Client
+	once     sync.Once
+)
+
+func LoadConfig() *Config {
+	config := &Config{}
+	if err := env.Parse(config); err != nil {
+		logger.Error("Failed to parse HTTP client config", "error", err)
+		panic(err)
+	}
+	if config.ProxyURL != nil {
+		switch config.ProxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			err := fmt.Errorf("unsupported proxy scheme %q", config.ProxyURL.Scheme)
+			logger.Error("Failed to parse HTTP client config", "error", err)
+			panic(err)
+		}
+	}
+	return config
+}
+
+// GetClient returns the client shared by the fetcher and the scraper
+func GetClient() *http.Client {
+	once.Do(func() {
+		instance = New(LoadConfig())
+	})
+	return instance
+}
+
+// New creates a client sending requests through a polite transport, retrying them when they fail
+// transiently, authenticated when they are made with a WithAuth context and conditionally when they
+// are made with a Revalidation context
+func New(config *Config) *http.Client {
+	proxy := http.ProxyFromEnvironment
+	if config.ProxyURL != nil {
+		proxy = http.ProxyURL(config.ProxyURL)
+	}
+
+	base := &http.Transport{
+		Proxy:               proxy,
+		DialContext:         (&net.Dialer{Timeout: config.DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout: config.TLSHandshakeTimeout,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	// Timeouts apply to each attempt rather than to the client, so that the time spent waiting for
+	// the rate limit or between retries is not counted
+	var transport http.RoundTripper = &timeoutTransport{base: base, timeout: config.Timeout}
+	transport = newPoliteTransport(transport, config)
+	transport = &retryTransport{base: transport, config: config}
+	transport = &authTransport{base: transport}
+
+	return &http.Client{
+		Transport: &conditionalTransport{base: transport},
+	}
+}
@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gkettani/bobber-the-swe/internal/cache"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+// ErrNotModified is returned for conditional requests answered with 304 Not Modified
+var ErrNotModified = errors.New("not modified since the previous request")
+
+// validatorsKeyPrefix prefixes the cache keys of the validators stored per URL
+const validatorsKeyPrefix = "http_validators:"
+
+type validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+type revalidationKey struct{}
+
+// Revalidation makes the GET requests sent with its context conditional, with the ETag and Last-Modified
+// of the previous responses to the same URLs. The validators of new responses are only stored on Commit,
+// once they were processed, so that a response that failed to be processed is not skipped next time.
+type Revalidation struct {
+	store cache.Manager
+
+	mu      sync.Mutex
+	pending map[string]validators
+}
+
+func NewRevalidation(store cache.Manager) *Revalidation {
+	return &Revalidation{
+		store:   store,
+		pending: make(map[string]validators),
+	}
+}
+
+// Context returns a context making the GET requests sent with it conditional
+func (r *Revalidation) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidationKey{}, r)
+}
+
+// Unconditional returns a context whose requests are not conditional, for content that is needed
+// even if it did not change, like the following pages of a listing
+func Unconditional(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidationKey{}, (*Revalidation)(nil))
+}
+
+// Forget drops the validators of a response received with a Revalidation context, so that its URL is
+// requested in full next time
+func Forget(ctx context.Context, url string) {
+	r, _ := ctx.Value(revalidationKey{}).(*Revalidation)
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, url)
+}
+
+// Commit stores the validators of the responses received
+func (r *Revalidation) Commit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for url, v := range r.pending {
+		value, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		r.store.Set(validatorsKeyPrefix+url, string(value))
+	}
+	r.pending = make(map[string]validators)
+}
+
+func (r *Revalidation) apply(req *http.Request) {
+	value, exists := r.store.Get(validatorsKeyPrefix + req.URL.String())
+	if !exists {
+		return
+	}
+
+	var v validators
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		logger.Debug("Ignoring invalid cached validators", "url", req.URL.String(), "error", err)
+		return
+	}
+
+	if v.ETag != "" && req.Header.Get("If-None-Match") == "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+func (r *Revalidation) record(url string, header http.Header) {
+	v := validators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	if v.ETag == "" && v.LastModified == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[url] = v
+}
+
+// conditionalTransport sends the requests made with a Revalidation context conditionally.
+// 304 responses are returned as is, for the caller to skip their processing.
+type conditionalTransport struct {
+	base http.RoundTripper
+}
+
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, _ := req.Context().Value(revalidationKey{}).(*Revalidation)
+	if r == nil || req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	r.apply(req)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		r.record(req.URL.String(), resp.Header)
+	}
+	return resp, nil
+}
+
+// NewValidatorCache creates the cache of response validators, in Redis when HTTP_CACHE_BACKEND is "redis"
+// so that they survive restarts, in memory otherwise
+func NewValidatorCache() cache.Manager {
+	if LoadConfig().CacheBackend == "redis" {
+		redisCache, err := cache.NewRedisCache()
+		if err == nil {
+			return redisCache
+		}
+		logger.Error("Failed to connect to Redis, caching response validators in memory", "error", err)
+	}
+	return cache.NewInMemoryCache()
+}
@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrForbidden   = errors.New("forbidden")
+	ErrRateLimited = errors.New("rate limited")
+	ErrTimeout     = errors.New("timed out")
+)
+
+// StatusError is returned for responses with an unexpected status code. It matches ErrNotFound, ErrForbidden,
+// ErrRateLimited and ErrNotModified with errors.Is, depending on the status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-OK status code: %d", e.StatusCode)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden || e.StatusCode == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrNotModified:
+		return e.StatusCode == http.StatusNotModified
+	}
+	return false
+}
+
+// CheckResponse returns nil for 200 OK responses, and a StatusError otherwise
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	return &StatusError{StatusCode: resp.StatusCode}
+}
+
+// ErrorType names the kind of a request error for metrics labels, or is empty for errors that are not typed
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	case errors.Is(err, ErrNotModified):
+		return "not_modified"
+	case errors.Is(err, ErrDisallowed):
+		return "robots_disallowed"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	}
+	return ""
+}
+
+// timeoutError marks a timed out request with ErrTimeout, keeping the original error
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrTimeout, e.err)
+}
+
+func (e *timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.err
+}
+
+// isTimeout checks if a transport error is a deadline being exceeded
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
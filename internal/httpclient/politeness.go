@@ -0,0 +1,227 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrDisallowed is returned for requests to URLs the host's robots.txt disallows
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// maxRobotsSize is how much of a robots.txt is read, RFC 9309 requiring crawlers to parse at least 500 KiB
+const maxRobotsSize = 500 * 1024
+
+// maxRobotsRedirects is how many redirects of a robots.txt are followed, as RFC 9309 requires
+const maxRobotsRedirects = 5
+
+// robotsRetryInterval is how long an unreachable robots.txt is assumed to disallow everything before it is
+// requested again, instead of the cache TTL of the fetched ones
+const robotsRetryInterval = time.Minute
+
+// politeTransport identifies the crawler, honors robots.txt and throttles the requests sent to each host
+type politeTransport struct {
+	base   http.RoundTripper
+	config *Config
+
+	mu     sync.Mutex
+	hosts  map[string]*hostState
+	waited *prometheus.HistogramVec
+}
+
+// hostState is the robots.txt and the token bucket of a host
+type hostState struct {
+	mu            sync.Mutex
+	robots        *robotsRules
+	robotsFetched time.Time
+	robotsTTL     time.Duration
+
+	interval time.Duration // time to refill one token
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+func newPoliteTransport(base http.RoundTripper, config *Config) *politeTransport {
+	return &politeTransport{
+		base:   base,
+		config: config,
+		hosts:  make(map[string]*hostState),
+		waited: metrics.GetManager().CreateHistogramVec(
+			"politeness_wait_seconds",
+			"Time requests waited for the rate limit of their host in seconds",
+			[]float64{0, 0.1, 0.5, 1, 2, 5, 10, 30},
+			[]string{"host"},
+		),
+	}
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.config.UserAgent)
+	}
+
+	host := t.host(req.URL.Scheme, req.URL.Host)
+
+	if t.config.RespectRobots {
+		robots := t.robots(req.Context(), host, req.URL.Scheme, req.URL.Host)
+		if !robots.allowed(req.URL.RequestURI()) {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowed, req.URL)
+		}
+	}
+
+	if err := t.wait(req.Context(), host, req.URL.Host); err != nil {
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+func (t *politeTransport) host(scheme, host string) *hostState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := scheme + "://" + host
+	state, exists := t.hosts[key]
+	if !exists {
+		state = &hostState{}
+		if t.config.HostRateLimit > 0 {
+			state.interval = time.Duration(float64(time.Second) / t.config.HostRateLimit)
+			state.burst = float64(max(t.config.HostBurst, 1))
+			state.tokens = state.burst
+		}
+		t.hosts[key] = state
+	}
+	return state
+}
+
+// wait blocks until the host's token bucket allows a request. Tokens are taken ahead of time,
+// so that concurrent requests queue up instead of being sent together once a token is available.
+func (t *politeTransport) wait(ctx context.Context, state *hostState, hostName string) error {
+	state.mu.Lock()
+	if state.interval == 0 {
+		state.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if !state.last.IsZero() {
+		state.tokens = min(state.burst, state.tokens+float64(now.Sub(state.last))/float64(state.interval))
+	}
+	state.last = now
+	state.tokens--
+
+	var delay time.Duration
+	if state.tokens < 0 {
+		delay = time.Duration(-state.tokens * float64(state.interval))
+	}
+	state.mu.Unlock()
+
+	t.waited.WithLabelValues(hostName).Observe(delay.Seconds())
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// robots returns the robots.txt rules of a host, fetching them when missing or expired. A Crawl-delay
+// slows the host's token bucket down to one request per delay.
+func (t *politeTransport) robots(ctx context.Context, state *hostState, scheme, hostName string) *robotsRules {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.robots != nil && time.Since(state.robotsFetched) < state.robotsTTL {
+		return state.robots
+	}
+
+	robots, err := t.fetchRobots(ctx, scheme, hostName)
+	if ctx.Err() != nil {
+		// The request was cancelled, not the robots.txt unreachable, and fails on its context
+		return allowAll
+	}
+	state.robotsFetched = time.Now()
+	state.robotsTTL = t.config.RobotsCacheTTL
+	if err != nil {
+		// RFC 9309 has crawlers assume an unreachable robots.txt disallows everything. The rules fetched
+		// before are kept, and the robots.txt requested again shortly.
+		logger.Warn(fmt.Sprintf("Failed to fetch the robots.txt of %s, retrying in %v: %v", hostName, robotsRetryInterval, err))
+		state.robotsTTL = robotsRetryInterval
+		if state.robots == nil {
+			state.robots = disallowAll
+		}
+		return state.robots
+	}
+	state.robots = robots
+
+	if delay := state.robots.crawlDelay; delay > state.interval {
+		if state.interval == 0 {
+			state.tokens = 1
+		}
+		state.interval = delay
+		state.burst = 1
+		state.tokens = min(state.tokens, 1)
+	}
+
+	return state.robots
+}
+
+// fetchRobots requests the robots.txt of a host, following up to five redirects. A missing robots.txt
+// allows everything, an error is returned when it is unreachable: the request failed, or the server
+// answered an error or too many requests.
+func (t *politeTransport) fetchRobots(ctx context.Context, scheme, hostName string) (*robotsRules, error) {
+	robotsURL := &url.URL{Scheme: scheme, Host: hostName, Path: "/robots.txt"}
+
+	for redirects := 0; ; redirects++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", t.config.UserAgent)
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode >= 300 && resp.StatusCode < 400:
+			location := resp.Header.Get("Location")
+			resp.Body.Close()
+
+			// Past five redirects, the robots.txt is deemed missing
+			next, err := robotsURL.Parse(location)
+			if location == "" || err != nil || redirects == maxRobotsRedirects {
+				return allowAll, nil
+			}
+			robotsURL = next
+			continue
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			resp.Body.Close()
+			return nil, fmt.Errorf("robots.txt answered %d", resp.StatusCode)
+		case resp.StatusCode != http.StatusOK:
+			resp.Body.Close()
+			return allowAll, nil
+		}
+
+		defer resp.Body.Close()
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), t.config.UserAgent), nil
+	}
+}
@@ -0,0 +1,245 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const robotsTxt = `# robots.txt
+User-agent: Googlebot
+Disallow: /
+
+User-agent: bobber
+User-agent: otherbot
+Disallow: /admin
+Disallow: /*.pdf$
+Allow: /admin/jobs
+Crawl-delay: 0.2
+
+User-agent: *
+Disallow: /jobs
+`
+
+func TestParseRobots(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{name: "unlisted path", userAgent: "bobber/1.0", path: "/jobs/101", want: true},
+		{name: "disallowed prefix", userAgent: "bobber/1.0", path: "/admin/users", want: false},
+		{name: "longest rule wins", userAgent: "bobber/1.0", path: "/admin/jobs/101", want: true},
+		{name: "anchored wildcard", userAgent: "bobber/1.0", path: "/files/offer.pdf", want: false},
+		{name: "anchored wildcard with suffix", userAgent: "bobber/1.0", path: "/files/offer.pdf?v=2", want: true},
+		{name: "fallback group", userAgent: "crawler/2.0", path: "/jobs/101", want: false},
+		{name: "robots.txt itself", userAgent: "googlebot", path: "/robots.txt", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(robotsTxt), tt.userAgent)
+			if got := rules.allowed(tt.path); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if delay := parseRobots(strings.NewReader(robotsTxt), "bobber/1.0").crawlDelay; delay != 200*time.Millisecond {
+		t.Errorf("Expected a crawl delay of 200ms, got: %v", delay)
+	}
+
+	// Groups of other product tokens, including prefixes of ours, and of an empty user-agent do not apply
+	const otherGroups = `User-agent: bob
+Disallow: /
+
+User-agent:
+Disallow: /
+
+User-agent: *
+Disallow: /private
+`
+	rules := parseRobots(strings.NewReader(otherGroups), "Bobber/1.0")
+	if !rules.allowed("/jobs/101") || rules.allowed("/private/101") {
+		t.Errorf("Expected the * group to apply, got: %+v", rules)
+	}
+	if rules := parseRobots(strings.NewReader("User-agent: BOBBER\nDisallow: /\n"), "bobber/1.0"); rules.allowed("/jobs/101") {
+		t.Errorf("Expected the bobber group to apply whatever its case, got: %+v", rules)
+	}
+}
+
+func TestPoliteTransport(t *testing.T) {
+	var requests atomic.Int32
+	var userAgent atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, robotsTxt)
+			return
+		}
+		requests.Add(1)
+		userAgent.Store(r.Header.Get("User-Agent"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		config      Config
+		path        string
+		count       int
+		wantErr     error
+		wantMinTime time.Duration
+	}{
+		{
+			name:   "burst is not delayed",
+			config: Config{UserAgent: "bobber/1.0", HostRateLimit: 1, HostBurst: 3},
+			path:   "/jobs/101",
+			count:  3,
+		},
+		{
+			name:        "requests beyond the burst wait for a token",
+			config:      Config{UserAgent: "bobber/1.0", HostRateLimit: 20, HostBurst: 1},
+			path:        "/jobs/101",
+			count:       3,
+			wantMinTime: 100 * time.Millisecond,
+		},
+		{
+			name:        "crawl delay",
+			config:      Config{UserAgent: "bobber/1.0", RespectRobots: true, RobotsCacheTTL: time.Hour},
+			path:        "/jobs/101",
+			count:       2,
+			wantMinTime: 200 * time.Millisecond,
+		},
+		{
+			name:    "disallowed by robots.txt",
+			config:  Config{UserAgent: "bobber/1.0", RespectRobots: true, RobotsCacheTTL: time.Hour},
+			path:    "/admin/users",
+			count:   1,
+			wantErr: ErrDisallowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			client := New(&tt.config)
+
+			start := time.Now()
+			for i := 0; i < tt.count; i++ {
+				req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+tt.path, nil)
+				resp, err := client.Do(req)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("Expected error %v, got: %v", tt.wantErr, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+				resp.Body.Close()
+			}
+			elapsed := time.Since(start)
+
+			if tt.wantErr != nil {
+				if requests.Load() != 0 {
+					t.Errorf("Expected no request to reach the server, got: %d", requests.Load())
+				}
+				return
+			}
+
+			if elapsed < tt.wantMinTime {
+				t.Errorf("Expected requests to take at least %v, took: %v", tt.wantMinTime, elapsed)
+			}
+			if tt.wantMinTime == 0 && elapsed > 500*time.Millisecond {
+				t.Errorf("Expected requests not to be delayed, took: %v", elapsed)
+			}
+			if got := userAgent.Load(); got != "bobber/1.0" {
+				t.Errorf("Expected User-Agent bobber/1.0, got: %v", got)
+			}
+		})
+	}
+}
+
+func TestPoliteTransport_FetchRobots(t *testing.T) {
+	tests := []struct {
+		name        string
+		robots      func(w http.ResponseWriter, r *http.Request)
+		wantAllowed bool
+		wantTTL     time.Duration
+	}{
+		{
+			name:        "missing robots.txt",
+			robots:      func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+			wantAllowed: true,
+			wantTTL:     time.Hour,
+		},
+		{
+			name:        "server error",
+			robots:      func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+			wantAllowed: false,
+			wantTTL:     robotsRetryInterval,
+		},
+		{
+			name: "redirected robots.txt",
+			robots: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/robots.txt" {
+					http.Redirect(w, r, "/moved/robots.txt", http.StatusMovedPermanently)
+					return
+				}
+				fmt.Fprint(w, "User-agent: *\nDisallow: /jobs\n")
+			},
+			wantAllowed: false,
+			wantTTL:     time.Hour,
+		},
+		{
+			name: "too many redirects",
+			robots: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, r.URL.Path+"/next", http.StatusFound)
+			},
+			wantAllowed: true,
+			wantTTL:     time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/jobs") {
+					requests.Add(1)
+					return
+				}
+				tt.robots(w, r)
+			}))
+			defer server.Close()
+
+			config := &Config{UserAgent: "bobber/1.0", RespectRobots: true, RobotsCacheTTL: time.Hour}
+			transport := newPoliteTransport(http.DefaultTransport, config)
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/jobs/101", nil)
+			resp, err := transport.RoundTrip(req)
+			if tt.wantAllowed {
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+				resp.Body.Close()
+			} else if !errors.Is(err, ErrDisallowed) || requests.Load() != 0 {
+				t.Fatalf("Expected the request to be disallowed, got: %v", err)
+			}
+
+			// Unreachable robots.txt are requested again shortly, not after the cache TTL
+			state := transport.host(req.URL.Scheme, req.URL.Host)
+			if state.robotsTTL != tt.wantTTL {
+				t.Errorf("Expected the robots.txt to be cached for %v, got: %v", tt.wantTTL, state.robotsTTL)
+			}
+		})
+	}
+}
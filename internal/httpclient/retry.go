@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+// retryTransport retries requests failing with a transient error or status, waiting with an exponential
+// backoff with jitter, or as long as the Retry-After of 429 and 503 responses asks
+type retryTransport struct {
+	base   http.RoundTripper
+	config *Config
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		delay, retry := t.retryDelay(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+		if req.Body != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", bodyErr)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		logger.Debug(fmt.Sprintf("Retrying %s %s (attempt %d/%d) after %v", req.Method, req.URL, attempt+2, t.config.MaxRetries+1, delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryDelay tells whether a failed attempt is retried, and after how long
+func (t *retryTransport) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.config.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return 0, false
+	}
+
+	if err != nil {
+		// Robots.txt rules and cancellations do not change by retrying
+		if errors.Is(err, ErrDisallowed) || errors.Is(err, context.Canceled) {
+			return 0, false
+		}
+		return t.backoff(attempt), true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			// Waiting longer than the maximum delay is left to the next cycle
+			return wait, wait <= t.config.RetryMaxDelay
+		}
+		return t.backoff(attempt), true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return t.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// backoff doubles the base delay with each attempt, up to the maximum delay, and picks a random
+// delay between half and all of it so that clients do not retry in lockstep
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.config.RetryBaseDelay << attempt
+	if delay <= 0 || delay > t.config.RetryMaxDelay {
+		delay = t.config.RetryMaxDelay
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header, either a number of seconds or an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// timeoutTransport bounds each attempt of a request, reading its body included, and marks the errors
+// of attempts running out of time with ErrTimeout
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if isTimeout(err) && req.Context().Err() == nil {
+			return nil, &timeoutError{err: err}
+		}
+		return nil, err
+	}
+
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, ctx: ctx, parent: req.Context(), cancel: cancel}
+	return resp, nil
+}
+
+// timeoutBody releases the attempt's deadline once the body is closed
+type timeoutBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() == context.DeadlineExceeded && b.parent.Err() == nil {
+		return n, &timeoutError{err: err}
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
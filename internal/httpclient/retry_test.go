@@ -0,0 +1,162 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []int
+		retryAfter   string
+		delay        time.Duration
+		wantRequests int32
+		wantErr      error
+	}{
+		{
+			name:         "success is not retried",
+			responses:    []int{http.StatusOK},
+			wantRequests: 1,
+		},
+		{
+			name:         "service unavailable with retry after",
+			responses:    []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:   "0",
+			wantRequests: 2,
+		},
+		{
+			name:         "rate limited with backoff",
+			responses:    []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusOK},
+			wantRequests: 3,
+		},
+		{
+			name:         "retries exhausted",
+			responses:    []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			wantRequests: 3,
+			wantErr:      ErrRateLimited,
+		},
+		{
+			name:         "retry after beyond the maximum delay",
+			responses:    []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "3600",
+			wantRequests: 1,
+			wantErr:      ErrRateLimited,
+		},
+		{
+			name:         "not found is not retried",
+			responses:    []int{http.StatusNotFound, http.StatusOK},
+			wantRequests: 1,
+			wantErr:      ErrNotFound,
+		},
+		{
+			name:         "forbidden is not retried",
+			responses:    []int{http.StatusForbidden, http.StatusOK},
+			wantRequests: 1,
+			wantErr:      ErrForbidden,
+		},
+		{
+			name:         "timeout is retried",
+			responses:    []int{http.StatusOK, http.StatusOK},
+			delay:        200 * time.Millisecond,
+			wantRequests: 3,
+			wantErr:      ErrTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := int(requests.Add(1)) - 1
+				if tt.delay > 0 {
+					time.Sleep(tt.delay)
+				}
+				status := tt.responses[min(i, len(tt.responses)-1)]
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+				io.WriteString(w, "body")
+			}))
+			defer server.Close()
+
+			client := New(&Config{
+				UserAgent:      "bobber/1.0",
+				Timeout:        50 * time.Millisecond,
+				MaxRetries:     2,
+				RetryBaseDelay: time.Millisecond,
+				RetryMaxDelay:  10 * time.Millisecond,
+			})
+
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, strings.NewReader("payload"))
+			resp, err := client.Do(req)
+			if err == nil {
+				err = CheckResponse(resp)
+				resp.Body.Close()
+			}
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got: %v", tt.wantErr, err)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("Expected %d requests, got: %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "seconds", value: "120", want: 2 * time.Minute, wantOk: true},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantOk: true},
+		{name: "empty", value: "", wantOk: false},
+		{name: "invalid", value: "soon", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.value)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: &StatusError{StatusCode: http.StatusGone}, want: "not_found"},
+		{err: &StatusError{StatusCode: http.StatusUnauthorized}, want: "forbidden"},
+		{err: &StatusError{StatusCode: http.StatusNotModified}, want: "not_modified"},
+		{err: &StatusError{StatusCode: http.StatusInternalServerError}, want: ""},
+		{err: &timeoutError{err: context.DeadlineExceeded}, want: "timeout"},
+		{err: ErrDisallowed, want: "robots_disallowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if got := ErrorType(tt.err); got != tt.want {
+				t.Errorf("ErrorType(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package jsonquery evaluates JMESPath expressions (https://jmespath.org) against decoded JSON,
+// so that sources can be configured to read values from arbitrarily nested responses.
+package jsonquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+var (
+	compiled   = make(map[string]*jmespath.JMESPath)
+	compiledMu sync.RWMutex
+)
+
+// Validate checks that an expression is valid JMESPath
+func Validate(expression string) error {
+	_, err := compile(expression)
+	return err
+}
+
+// Search evaluates an expression against data decoded by encoding/json. An empty expression returns data itself.
+func Search(expression string, data interface{}) (interface{}, error) {
+	if expression == "" {
+		return data, nil
+	}
+
+	query, err := compile(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := query.Search(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %q: %w", expression, err)
+	}
+
+	return result, nil
+}
+
+// SearchArray evaluates an expression expected to select a list. Nested lists, as returned by
+// projections like departments[*].jobs[*], are flattened.
+func SearchArray(expression string, data interface{}) ([]interface{}, error) {
+	result, err := Search(expression, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("no value found at %q", expression)
+	}
+
+	array, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value at %q is not an array", expression)
+	}
+
+	return flatten(array), nil
+}
+
+// SearchString evaluates an expression expected to select a scalar and returns it as a string.
+// Missing values and values that are not scalars are returned as an empty string.
+func SearchString(expression string, data interface{}) (string, error) {
+	result, err := Search(expression, data)
+	if err != nil {
+		return "", err
+	}
+
+	return String(result), nil
+}
+
+// String formats a scalar JSON value, integers being formatted without a decimal part
+func String(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return ""
+	}
+}
+
+func compile(expression string) (*jmespath.JMESPath, error) {
+	compiledMu.RLock()
+	query, exists := compiled[expression]
+	compiledMu.RUnlock()
+	if exists {
+		return query, nil
+	}
+
+	query, err := jmespath.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", expression, err)
+	}
+
+	compiledMu.Lock()
+	compiled[expression] = query
+	compiledMu.Unlock()
+
+	return query, nil
+}
+
+func flatten(values []interface{}) []interface{} {
+	flat := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if nested, ok := value.([]interface{}); ok {
+			flat = append(flat, flatten(nested)...)
+			continue
+		}
+		flat = append(flat, value)
+	}
+	return flat
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 )
 
 func getLogLevel() slog.Level {
@@ -27,10 +28,90 @@ func getLogLevel() slog.Level {
 var logger *slog.Logger = slog.New(slog.NewJSONHandler(
 	os.Stdout,
 	&slog.HandlerOptions{
-		Level: getLogLevel(),
+		Level:       getLogLevel(),
+		ReplaceAttr: redactAttr,
 	},
 ))
 
+// Redacted replaces the registered secrets in the logs
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the length below which values are not redacted, as they would mask common words
+const minSecretLength = 4
+
+var (
+	secrets   map[string]int // number of registrations of each secret
+	redactor  *strings.Replacer
+	secretsMu sync.RWMutex
+)
+
+// RegisterSecret redacts a value from the messages and attributes logged from now on
+func RegisterSecret(secret string) {
+	ReplaceSecret("", secret)
+}
+
+// ReplaceSecret redacts a value from the logs in place of a registered one, e.g. a refreshed access token
+// in place of the expired one, so that the redacted values do not pile up. The replaced value is still
+// redacted while registered elsewhere.
+func ReplaceSecret(old, secret string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	changed := false
+	if count, exists := secrets[old]; exists {
+		if count > 1 {
+			secrets[old]--
+		} else {
+			delete(secrets, old)
+			changed = true
+		}
+	}
+	if len(secret) >= minSecretLength {
+		if secrets == nil {
+			secrets = make(map[string]int)
+		}
+		secrets[secret]++
+		changed = changed || secrets[secret] == 1
+	}
+	if !changed {
+		return
+	}
+
+	if len(secrets) == 0 {
+		redactor = nil
+		return
+	}
+	pairs := make([]string, 0, 2*len(secrets))
+	for s := range secrets {
+		pairs = append(pairs, s, Redacted)
+	}
+	redactor = strings.NewReplacer(pairs...)
+}
+
+// Redact replaces the registered secrets of a string
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	if redactor == nil {
+		return s
+	}
+	return redactor.Replace(s)
+}
+
+// redactAttr redacts the registered secrets of the string and error attributes, the message included
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, isError := attr.Value.Any().(error); isError {
+			attr.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return attr
+}
+
 func Info(msg string, args ...any) {
 	logger.Info(msg, args...)
 }
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactAttr(t *testing.T) {
+	RegisterSecret("hunter22-secret")
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttr}))
+	log.Info("Fetching with hunter22-secret",
+		"url", "https://api.example.com/jobs?token=hunter22-secret",
+		"error", errors.New("401 for hunter22-secret"),
+		"company", "acme",
+	)
+
+	output := buf.String()
+	if strings.Contains(output, "hunter22-secret") {
+		t.Errorf("Expected the secret to be redacted from the message and attributes, got: %s", output)
+	}
+	if got := strings.Count(output, Redacted); got != 3 {
+		t.Errorf("Expected 3 redactions, got %d: %s", got, output)
+	}
+	if !strings.Contains(output, `"company":"acme"`) {
+		t.Errorf("Expected the other attributes to be logged, got: %s", output)
+	}
+}
+
+func TestRegisterSecret_TooShort(t *testing.T) {
+	RegisterSecret("abc")
+	if got := Redact("abc def"); got != "abc def" {
+		t.Errorf("Redact() = %q, want short values left alone", got)
+	}
+}
+
+func TestReplaceSecret(t *testing.T) {
+	ReplaceSecret("", "access-token-1")
+	ReplaceSecret("access-token-1", "access-token-2")
+
+	if got := Redact("access-token-1 access-token-2"); got != "access-token-1 "+Redacted {
+		t.Errorf("Redact() = %q, want only the current token redacted", got)
+	}
+
+	// A value registered elsewhere stays redacted when one of its registrations is replaced
+	RegisterSecret("shared-secret")
+	RegisterSecret("shared-secret")
+	ReplaceSecret("shared-secret", "other-secret")
+	if got := Redact("shared-secret"); got != Redacted {
+		t.Errorf("Redact() = %q, want the shared secret still redacted", got)
+	}
+
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	if _, exists := secrets["access-token-1"]; exists {
+		t.Error("Expected the replaced token to be dropped from the secrets")
+	}
+}
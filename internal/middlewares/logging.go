@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -32,6 +33,27 @@ func LoadMiddlewareConfig() *MiddlewareConfig {
 	return config
 }
 
+// sensitiveHeaders are left out of the logged headers
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+}
+
+// sensitiveParams are the query parameters whose values are redacted from the logged URLs
+var sensitiveParams = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"apikey":        true,
+	"client_secret": true,
+	"key":           true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+}
+
 // RequestID key for context
 type contextKey string
 
@@ -126,31 +148,14 @@ func HTTPLoggingMiddlewareWithConfig(next http.Handler, config *MiddlewareConfig
 			}
 		}
 
-		// Extract query parameters
-		queryParams := make(map[string]string)
-		for key, values := range r.URL.Query() {
-			if len(values) > 0 {
-				queryParams[key] = values[0] // Take first value
-			}
-		}
+		// Extract query parameters, redacting credentials
+		redactedURL, queryParams := redactQuery(r.URL)
+		requestBody = logger.Redact(requestBody)
 
 		// Extract important headers (excluding sensitive ones) if configured
 		var headers map[string]string
 		if config.LogHeaders {
-			headers = make(map[string]string)
-			sensitiveHeaders := map[string]bool{
-				"authorization": true,
-				"cookie":        true,
-				"x-api-key":     true,
-				"x-auth-token":  true,
-			}
-
-			for name, values := range r.Header {
-				lowerName := strings.ToLower(name)
-				if !sensitiveHeaders[lowerName] && len(values) > 0 {
-					headers[name] = values[0]
-				}
-			}
+			headers = loggedHeaders(r.Header)
 		}
 
 		// Get client IP
@@ -170,9 +175,9 @@ func HTTPLoggingMiddlewareWithConfig(next http.Handler, config *MiddlewareConfig
 				logData := RequestLogData{
 					RequestID:     requestID,
 					Method:        r.Method,
-					URL:           r.URL.String(),
+					URL:           redactedURL.String(),
 					Path:          r.URL.Path,
-					RawQuery:      r.URL.RawQuery,
+					RawQuery:      redactedURL.RawQuery,
 					QueryParams:   queryParams,
 					Headers:       headers,
 					UserAgent:     r.Header.Get("User-Agent"),
@@ -203,9 +208,9 @@ func HTTPLoggingMiddlewareWithConfig(next http.Handler, config *MiddlewareConfig
 		logData := RequestLogData{
 			RequestID:     requestID,
 			Method:        r.Method,
-			URL:           r.URL.String(),
+			URL:           redactedURL.String(),
 			Path:          r.URL.Path,
-			RawQuery:      r.URL.RawQuery,
+			RawQuery:      redactedURL.RawQuery,
 			QueryParams:   queryParams,
 			Headers:       headers,
 			UserAgent:     r.Header.Get("User-Agent"),
@@ -222,7 +227,7 @@ func HTTPLoggingMiddlewareWithConfig(next http.Handler, config *MiddlewareConfig
 
 		// Add response body for successful API calls if configured
 		if config.LogResponseBody && rw.statusCode < 400 && strings.HasPrefix(r.URL.Path, "/api/") && rw.body.Len() > 0 {
-			responseBody := rw.body.String()
+			responseBody := logger.Redact(rw.body.String())
 			if int64(len(responseBody)) <= config.MaxResponseBodySize {
 				logData.ResponseBody = responseBody
 			} else {
@@ -241,6 +246,45 @@ func HTTPLoggingMiddlewareWithConfig(next http.Handler, config *MiddlewareConfig
 	})
 }
 
+// redactQuery returns the URL and the query parameters of a request as logged, the values of the sensitive
+// parameters and the registered secrets being redacted
+func redactQuery(requestURL *url.URL) (url.URL, map[string]string) {
+	queryParams := make(map[string]string)
+	query := requestURL.Query()
+	for key, values := range query {
+		for i := range values {
+			if sensitiveParams[strings.ToLower(key)] {
+				values[i] = logger.Redacted
+			} else {
+				values[i] = logger.Redact(values[i])
+			}
+		}
+		if len(values) > 0 {
+			queryParams[key] = values[0] // Take first value
+		}
+	}
+
+	redactedURL := *requestURL
+	redactedURL.Path = logger.Redact(requestURL.Path)
+	redactedURL.RawPath = ""
+	if len(query) > 0 {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return redactedURL, queryParams
+}
+
+// loggedHeaders returns the first value of the headers of a request as logged, leaving the sensitive
+// headers out and redacting the registered secrets
+func loggedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for name, values := range header {
+		if !sensitiveHeaders[strings.ToLower(name)] && len(values) > 0 {
+			headers[name] = logger.Redact(values[0])
+		}
+	}
+	return headers
+}
+
 // WrapHandler wraps a single handler with logging middleware
 func WrapHandler(handler http.HandlerFunc) http.HandlerFunc {
 	return HTTPLoggingMiddleware(http.HandlerFunc(handler)).ServeHTTP
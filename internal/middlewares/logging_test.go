@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+func TestRedactQuery(t *testing.T) {
+	logger.RegisterSecret("registered-secret")
+
+	requestURL, _ := url.Parse("/api/jobs/registered-secret?Token=abc123&api_key=k&q=registered-secret&company=acme")
+	redactedURL, queryParams := redactQuery(requestURL)
+
+	for key, want := range map[string]string{
+		"Token":   logger.Redacted,
+		"api_key": logger.Redacted,
+		"q":       logger.Redacted,
+		"company": "acme",
+	} {
+		if got := queryParams[key]; got != want {
+			t.Errorf("Query parameter %s = %q, want %q", key, got, want)
+		}
+	}
+
+	logged := redactedURL.String()
+	for _, secret := range []string{"abc123", "registered-secret"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %s to be redacted from the logged URL, got: %s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "company=acme") {
+		t.Errorf("Expected the other parameters to be logged, got: %s", logged)
+	}
+
+	// The request itself is left untouched
+	if requestURL.Query().Get("Token") != "abc123" {
+		t.Errorf("Expected the request URL not to be modified, got: %s", requestURL)
+	}
+}
+
+func TestLoggedHeaders(t *testing.T) {
+	logger.RegisterSecret("registered-secret")
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc123")
+	header.Set("Cookie", "session=abc123")
+	header.Set("X-Api-Key", "abc123")
+	header.Set("X-Custom", "value registered-secret")
+	header.Set("Accept", "application/json")
+
+	headers := loggedHeaders(header)
+	for _, name := range []string{"Authorization", "Cookie", "X-Api-Key"} {
+		if value, exists := headers[name]; exists {
+			t.Errorf("Expected header %s to be left out, got: %q", name, value)
+		}
+	}
+	if got := headers["X-Custom"]; got != "value "+logger.Redacted {
+		t.Errorf("X-Custom = %q, want the registered secret redacted", got)
+	}
+	if got := headers["Accept"]; got != "application/json" {
+		t.Errorf("Accept = %q, want application/json", got)
+	}
+}
+
+func TestHTTPLoggingMiddleware_ForwardsRequest(t *testing.T) {
+	var gotToken, gotBody string
+	handler := HTTPLoggingMiddlewareWithConfig(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.URL.Query().Get("token")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}), &MiddlewareConfig{LogRequestBody: true, MaxRequestBodySize: 1024, LogHeaders: true})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/jobs?token=abc123", strings.NewReader(`{"a":1}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Redacting the logs does not change what the handler receives
+	if gotToken != "abc123" || gotBody != `{"a":1}` {
+		t.Errorf("Expected the handler to receive the request as sent, got token %q and body %q", gotToken, gotBody)
+	}
+}
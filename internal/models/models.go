@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 // JobReference represents a reference to a job posting found during discovery.
 // It contains the minimal information needed to locate and identify a job posting.
@@ -13,6 +16,41 @@ type JobReference struct {
 
 	// CompanyName is the name of the company offering the job
 	CompanyName string
+
+	// CompanyKey is the registry key of the company configuration the reference was discovered with,
+	// telling apart the configurations sharing a company name (e.g. one per sitemap)
+	CompanyKey string
+
+	// Source is the applicant tracking system the reference was discovered from
+	// (e.g. "greenhouse"). It is empty for generic sources such as sitemaps.
+	Source string
+
+	// APIURL is the machine-readable endpoint of the posting, when the source exposes one
+	APIURL string
+
+	// Title, Location and Description are captured at discovery time when the source lists them,
+	// sparing the enrichment of complete references
+	Title       string
+	Location    string
+	Description string
+
+	// DatePosted is when the posting was published, when the source reports it (e.g. feed entries)
+	DatePosted *time.Time
+
+	// Details are the full details of the posting, when the source lists them all (e.g. Ashby job boards),
+	// sparing the enrichment of the reference
+	Details *JobDetails
+
+	// LastModified is when the source last changed the posting, when it reports it (e.g. sitemap <lastmod>)
+	LastModified *time.Time
+
+	// Unchanged is set when the source reports no change since the previous discovery,
+	// so the reference is still listed but does not need to be enriched again
+	Unchanged bool
+
+	// Retries is the number of failed processings of a reference retried from the dead-letter store,
+	// zero for discovered references
+	Retries int
 }
 
 // IsValid checks if the job reference has all required fields
@@ -20,23 +58,81 @@ func (jr *JobReference) IsValid() bool {
 	return jr.URL != "" && jr.ExternalID != "" && jr.CompanyName != ""
 }
 
+// Key identifies the posting of a job reference. External IDs are only unique within the system of a
+// company, so the key also holds the company and the source it was discovered from.
+func (jr *JobReference) Key() string {
+	return url.QueryEscape(jr.Source) + ":" + url.QueryEscape(jr.CompanyName) + ":" + url.QueryEscape(jr.ExternalID)
+}
+
+// IsComplete checks if the job reference was discovered with enough details to skip enrichment
+func (jr *JobReference) IsComplete() bool {
+	return jr.IsValid() && (jr.Details != nil || (jr.Title != "" && jr.Description != ""))
+}
+
+// ToJobDetails builds job details from the fields captured at discovery time
+func (jr *JobReference) ToJobDetails() *JobDetails {
+	details := &JobDetails{}
+	if jr.Details != nil {
+		*details = *jr.Details
+	}
+	details.ExternalID = jr.ExternalID
+	details.URL = jr.URL
+	details.FillFrom(jr)
+	return details
+}
+
 // JobDetails represents complete information about a job posting.
 // This is the enriched version created after scraping the job reference.
 type JobDetails struct {
-	ID          int64     `db:"id" json:"id"`
-	ExternalID  string    `db:"external_id" json:"externalId"`
-	CompanyName string    `db:"company_name" json:"companyName"`
-	URL         string    `db:"url" json:"url"`
-	Title       string    `db:"title" json:"title"`
-	Location    string    `db:"location" json:"location"`
-	Description string    `db:"description" json:"description"`
-	Hash        string    `json:"-"` // for change detection
-	FirstSeenAt time.Time `db:"first_seen_at" json:"firstSeenAt"`
-	LastSeenAt  time.Time `db:"last_seen_at" json:"lastSeenAt"`
-	ExpiredAt   time.Time `db:"expired_at" json:"expiredAt"`
+	ID                 int64      `db:"id" json:"id"`
+	ExternalID         string     `db:"external_id" json:"externalId"`
+	CompanyName        string     `db:"company_name" json:"companyName"`
+	Source             string     `db:"source" json:"source,omitempty"` // as the job reference, part of the identity of the job
+	CompanyKey         string     `db:"company_key" json:"-"`           // configuration whose discoveries the job is expired by
+	URL                string     `db:"url" json:"url"`
+	Title              string     `db:"title" json:"title"`
+	Location           string     `db:"location" json:"location"`
+	Description        string     `db:"description" json:"description"`
+	Department         string     `db:"department" json:"department,omitempty"`
+	Offices            string     `db:"offices" json:"offices,omitempty"`
+	Team               string     `db:"team" json:"team,omitempty"`
+	EmploymentType     string     `db:"employment_type" json:"employmentType,omitempty"`      // e.g. Lever's commitment
+	WorkplaceType      string     `db:"workplace_type" json:"workplaceType,omitempty"`        // on-site, remote or hybrid
+	PostingUpdatedAt   *time.Time `db:"posting_updated_at" json:"postingUpdatedAt,omitempty"` // as reported by the source
+	DatePosted         *time.Time `db:"date_posted" json:"datePosted,omitempty"`
+	ValidThrough       *time.Time `db:"valid_through" json:"validThrough,omitempty"`
+	SalaryMin          *float64   `db:"salary_min" json:"salaryMin,omitempty"`
+	SalaryMax          *float64   `db:"salary_max" json:"salaryMax,omitempty"`
+	SalaryCurrency     string     `db:"salary_currency" json:"salaryCurrency,omitempty"`
+	SalaryUnit         string     `db:"salary_unit" json:"salaryUnit,omitempty"` // e.g. YEAR, MONTH or HOUR
+	HiringOrganization string     `db:"hiring_organization" json:"hiringOrganization,omitempty"`
+	Hash               string     `db:"hash" json:"-"` // content hash, for change detection
+	FirstSeenAt        time.Time  `db:"first_seen_at" json:"firstSeenAt"`
+	LastSeenAt         time.Time  `db:"last_seen_at" json:"lastSeenAt"`
+	ExpiredAt          time.Time  `db:"expired_at" json:"expiredAt"`
 }
 
 // IsValid checks if the job details have all required fields
 func (jd *JobDetails) IsValid() bool {
 	return jd.ExternalID != "" && jd.CompanyName != "" && jd.URL != "" && jd.Title != ""
 }
+
+// FillFrom completes the details left empty by enrichment with the fields captured at discovery time,
+// and identifies them as the reference, which deduplication and expiry look jobs up by
+func (jd *JobDetails) FillFrom(jr *JobReference) {
+	jd.Source = jr.Source
+	jd.CompanyName = jr.CompanyName
+	jd.CompanyKey = jr.CompanyKey
+	if jd.Title == "" {
+		jd.Title = jr.Title
+	}
+	if jd.Location == "" {
+		jd.Location = jr.Location
+	}
+	if jd.Description == "" {
+		jd.Description = jr.Description
+	}
+	if jd.DatePosted == nil {
+		jd.DatePosted = jr.DatePosted
+	}
+}
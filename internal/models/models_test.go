@@ -0,0 +1,59 @@
+package models
+
+import "testing"
+
+func TestJobReference_Key(t *testing.T) {
+	base := JobReference{Source: "lever", CompanyName: "acme", ExternalID: "backend-engineer"}
+
+	tests := []struct {
+		name     string
+		other    JobReference
+		wantSame bool
+	}{
+		{
+			name:     "same posting",
+			other:    JobReference{Source: "lever", CompanyName: "acme", ExternalID: "backend-engineer", URL: "https://acme.com/jobs/1"},
+			wantSame: true,
+		},
+		{
+			name:  "other company",
+			other: JobReference{Source: "lever", CompanyName: "globex", ExternalID: "backend-engineer"},
+		},
+		{
+			name:  "other source",
+			other: JobReference{Source: "greenhouse", CompanyName: "acme", ExternalID: "backend-engineer"},
+		},
+		{
+			name:  "separator in fields",
+			other: JobReference{Source: "lever:acme", CompanyName: "", ExternalID: "backend-engineer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := tt.other.Key() == base.Key(); same != tt.wantSame {
+				t.Errorf("Key() same = %v, want %v (%q, %q)", same, tt.wantSame, tt.other.Key(), base.Key())
+			}
+		})
+	}
+}
+
+func TestJobDetails_FillFrom(t *testing.T) {
+	jobRef := &JobReference{
+		Source:      "lever",
+		CompanyName: "Acme",
+		ExternalID:  "backend-engineer",
+		Title:       "Backend Engineer",
+		Location:    "Paris",
+	}
+	details := &JobDetails{CompanyName: "Acme Corp", Title: "Senior Backend Engineer"}
+
+	details.FillFrom(jobRef)
+
+	if details.Source != "lever" || details.CompanyName != "Acme" {
+		t.Errorf("FillFrom() identity = %q/%q, want the reference's lever/Acme", details.Source, details.CompanyName)
+	}
+	if details.Title != "Senior Backend Engineer" || details.Location != "Paris" {
+		t.Errorf("FillFrom() title = %q, location = %q", details.Title, details.Location)
+	}
+}
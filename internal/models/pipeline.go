@@ -1,8 +1,6 @@
 package models
 
-import (
-	"time"
-)
+import "time"
 
 // PipelineStatus represents the overall status of the job processing pipeline
 type PipelineStatus struct {
@@ -13,20 +11,40 @@ type PipelineStatus struct {
 	DiscoveryCompanies  int `json:"discovery_companies"`
 	EnrichmentCompanies int `json:"enrichment_companies"`
 
+	// Enrichment pool size and workers processing a job reference
+	EnrichmentWorkers int `json:"enrichment_workers"`
+	BusyWorkers       int `json:"busy_workers"`
+
 	// Runtime information
 	StartTime time.Time `json:"start_time"`
 	Uptime    string    `json:"uptime"`
 
+	// Discovery schedule of each company, ordered by next run
+	Schedules []CompanySchedule `json:"schedules"`
+
 	// Processing metrics
 	Metrics ProcessingMetrics `json:"metrics"`
 }
 
+// CompanySchedule is when the discovery of a company last ran and runs next
+type CompanySchedule struct {
+	Company  string    `json:"company"`
+	Schedule string    `json:"schedule"`
+	NextRun  time.Time `json:"next_run"`
+	LastRun  time.Time `json:"last_run,omitempty"`
+	Running  bool      `json:"running"`
+}
+
 // ProcessingMetrics contains metrics about job processing
 type ProcessingMetrics struct {
-	// Discovery metrics
+	// Discovery metrics, a cycle being the discovery of one company
 	DiscoveryCycles     int64     `json:"discovery_cycles"`
 	LastDiscoveryTime   time.Time `json:"last_discovery_time"`
 	TotalJobsDiscovered int64     `json:"total_jobs_discovered"`
+	DiscoveryFailures   int64     `json:"discovery_failures"`
+
+	// Errors of the companies whose last discovery failed, by company
+	LastDiscoveryErrors map[string]string `json:"last_discovery_errors,omitempty"`
 
 	// Enrichment metrics
 	JobsProcessed  int64 `json:"jobs_processed"`
@@ -34,6 +52,9 @@ type ProcessingMetrics struct {
 	JobsFailed     int64 `json:"jobs_failed"`
 	JobsDuplicate  int64 `json:"jobs_duplicate"`
 
+	// Jobs expired because they were missing from discoveries or their posting was not found
+	JobsExpired int64 `json:"jobs_expired"`
+
 	// Performance metrics
 	AverageProcessingTime time.Duration `json:"average_processing_time"`
 	TotalProcessingTime   time.Duration `json:"total_processing_time"`
@@ -49,6 +70,7 @@ type ProcessingResult struct {
 	Status         ProcessingStatus `json:"status"`
 	JobDetails     *JobDetails      `json:"job_details,omitempty"`
 	Error          string           `json:"error,omitempty"`
+	ErrorClass     string           `json:"error_class,omitempty"`
 	ProcessingTime time.Duration    `json:"processing_time"`
 	Timestamp      time.Time        `json:"timestamp"`
 }
@@ -61,6 +83,7 @@ const (
 	ProcessingStatusFailed    ProcessingStatus = "failed"
 	ProcessingStatusDuplicate ProcessingStatus = "duplicate"
 	ProcessingStatusSkipped   ProcessingStatus = "skipped"
+	ProcessingStatusExpired   ProcessingStatus = "expired"
 )
 
 // CalculateSuccessRate calculates the success rate for processing metrics
@@ -70,3 +93,20 @@ func (pm *ProcessingMetrics) CalculateSuccessRate() float64 {
 	}
 	return float64(pm.JobsSuccessful) / float64(pm.JobsProcessed) * 100.0
 }
+
+// DeadLetter is a job reference whose processing failed, retried at NextRetryAt. NextRetryAt is nil once
+// the reference ran out of attempts, until it is retried by hand.
+type DeadLetter struct {
+	ID            int64      `db:"id" json:"id"`
+	Source        string     `db:"source" json:"source,omitempty"`
+	CompanyName   string     `db:"company_name" json:"company"`
+	CompanyKey    string     `db:"company_key" json:"company_key"` // registry key of the company configuration
+	ExternalID    string     `db:"external_id" json:"external_id"`
+	URL           string     `db:"url" json:"url"`
+	ErrorClass    string     `db:"error_class" json:"error_class"`
+	LastError     string     `db:"last_error" json:"last_error"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	FirstFailedAt time.Time  `db:"first_failed_at" json:"first_failed_at"`
+	LastFailedAt  time.Time  `db:"last_failed_at" json:"last_failed_at"`
+	NextRetryAt   *time.Time `db:"next_retry_at" json:"next_retry_at"`
+}
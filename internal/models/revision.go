@@ -0,0 +1,121 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jobField is a field of job details compared to detect changes, by its normalized value
+type jobField struct {
+	name  string
+	value func(jd *JobDetails) string
+}
+
+// jobFields are the fields of job details hashed for change detection. PostingUpdatedAt is left out, as
+// sources bump it without changing the posting.
+var jobFields = []jobField{
+	{"url", func(jd *JobDetails) string { return normalizeText(jd.URL) }},
+	{"title", func(jd *JobDetails) string { return normalizeText(jd.Title) }},
+	{"location", func(jd *JobDetails) string { return normalizeText(jd.Location) }},
+	{"description", func(jd *JobDetails) string { return normalizeText(jd.Description) }},
+	{"department", func(jd *JobDetails) string { return normalizeText(jd.Department) }},
+	{"offices", func(jd *JobDetails) string { return normalizeText(jd.Offices) }},
+	{"team", func(jd *JobDetails) string { return normalizeText(jd.Team) }},
+	{"employmentType", func(jd *JobDetails) string { return normalizeText(jd.EmploymentType) }},
+	{"workplaceType", func(jd *JobDetails) string { return normalizeText(jd.WorkplaceType) }},
+	{"datePosted", func(jd *JobDetails) string { return normalizeTime(jd.DatePosted) }},
+	{"validThrough", func(jd *JobDetails) string { return normalizeTime(jd.ValidThrough) }},
+	{"salaryMin", func(jd *JobDetails) string { return normalizeNumber(jd.SalaryMin) }},
+	{"salaryMax", func(jd *JobDetails) string { return normalizeNumber(jd.SalaryMax) }},
+	{"salaryCurrency", func(jd *JobDetails) string { return strings.ToUpper(normalizeText(jd.SalaryCurrency)) }},
+	{"salaryUnit", func(jd *JobDetails) string { return strings.ToUpper(normalizeText(jd.SalaryUnit)) }},
+	{"hiringOrganization", func(jd *JobDetails) string { return normalizeText(jd.HiringOrganization) }},
+}
+
+// ContentHash hashes the normalized fields of the job details, so that postings differing only in
+// whitespace or time zones hash the same
+func (jd *JobDetails) ContentHash() string {
+	h := sha256.New()
+	for _, field := range jobFields {
+		h.Write([]byte(field.name))
+		h.Write([]byte{0})
+		h.Write([]byte(field.value(jd)))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func normalizeTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func normalizeNumber(n *float64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*n, 'f', -1, 64)
+}
+
+// JobRevision is a previous version of a job, stored when its content changed
+type JobRevision struct {
+	JobDetails
+
+	// RecordedAt is when the version was replaced
+	RecordedAt time.Time `db:"recorded_at" json:"recordedAt"`
+}
+
+// JobHistory lists the changes of a job, most recent first
+type JobHistory struct {
+	JobID   int64       `json:"jobId"`
+	Changes []JobChange `json:"changes"`
+}
+
+// JobChange is an update of the content of a job
+type JobChange struct {
+	ChangedAt time.Time   `json:"changedAt"`
+	Fields    []FieldDiff `json:"fields"`
+}
+
+// FieldDiff is the normalized value of a field before and after a change
+type FieldDiff struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// DiffJobDetails returns the fields that differ between two versions of a job
+func DiffJobDetails(before, after *JobDetails) []FieldDiff {
+	var diffs []FieldDiff
+	for _, field := range jobFields {
+		beforeValue, afterValue := field.value(before), field.value(after)
+		if beforeValue != afterValue {
+			diffs = append(diffs, FieldDiff{Field: field.name, Before: beforeValue, After: afterValue})
+		}
+	}
+	return diffs
+}
+
+// NewJobHistory builds the history of a job from its current version and its revisions, most recent first
+func NewJobHistory(current *JobDetails, revisions []*JobRevision) *JobHistory {
+	history := &JobHistory{JobID: current.ID, Changes: make([]JobChange, 0, len(revisions))}
+
+	after := current
+	for _, revision := range revisions {
+		history.Changes = append(history.Changes, JobChange{
+			ChangedAt: revision.RecordedAt,
+			Fields:    DiffJobDetails(&revision.JobDetails, after),
+		})
+		after = &revision.JobDetails
+	}
+	return history
+}
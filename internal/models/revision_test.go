@@ -0,0 +1,99 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJobDetails_ContentHash(t *testing.T) {
+	salary := 100000.0
+	posted := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	postedParis := posted.In(time.FixedZone("CET", 3600))
+	updated := time.Now()
+
+	base := JobDetails{
+		URL:         "https://acme.com/jobs/1",
+		Title:       "Backend Engineer",
+		Location:    "Paris",
+		Description: "Build things.",
+		SalaryMin:   &salary,
+		DatePosted:  &posted,
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(jd *JobDetails)
+		wantSame bool
+	}{
+		{
+			name:     "whitespace",
+			modify:   func(jd *JobDetails) { jd.Title = "  Backend\n Engineer " },
+			wantSame: true,
+		},
+		{
+			name:     "time zone",
+			modify:   func(jd *JobDetails) { jd.DatePosted = &postedParis },
+			wantSame: true,
+		},
+		{
+			name:     "posting update time",
+			modify:   func(jd *JobDetails) { jd.PostingUpdatedAt = &updated },
+			wantSame: true,
+		},
+		{
+			name:   "title",
+			modify: func(jd *JobDetails) { jd.Title = "Senior Backend Engineer" },
+		},
+		{
+			name:   "salary removed",
+			modify: func(jd *JobDetails) { jd.SalaryMin = nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := base
+			tt.modify(&modified)
+
+			if same := modified.ContentHash() == base.ContentHash(); same != tt.wantSame {
+				t.Errorf("ContentHash() same = %v, want %v", same, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestNewJobHistory(t *testing.T) {
+	recordedFirst := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	recordedSecond := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	current := &JobDetails{ID: 7, Title: "Staff Engineer", Location: "Remote", Description: "Lead things."}
+	revisions := []*JobRevision{
+		{
+			JobDetails: JobDetails{ID: 7, Title: "Senior Engineer", Location: "Remote", Description: "Lead things."},
+			RecordedAt: recordedSecond,
+		},
+		{
+			JobDetails: JobDetails{ID: 7, Title: "Senior Engineer", Location: "Paris", Description: "Lead things."},
+			RecordedAt: recordedFirst,
+		},
+	}
+
+	want := &JobHistory{
+		JobID: 7,
+		Changes: []JobChange{
+			{
+				ChangedAt: recordedSecond,
+				Fields:    []FieldDiff{{Field: "title", Before: "Senior Engineer", After: "Staff Engineer"}},
+			},
+			{
+				ChangedAt: recordedFirst,
+				Fields:    []FieldDiff{{Field: "location", Before: "Paris", After: "Remote"}},
+			},
+		},
+	}
+
+	if got := NewJobHistory(current, revisions); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewJobHistory() = %+v, want %+v", got, want)
+	}
+}
@@ -1,54 +1,154 @@
 package queue
 
 import (
+	"context"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gkettani/bobber-the-swe/internal/metrics"
 	"github.com/gkettani/bobber-the-swe/internal/models"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-type JobQueue struct {
+// InMemoryQueue queues job references in memory, losing them on restart
+type InMemoryQueue struct {
 	items          []*models.JobReference
+	claimed        map[string]claimedReference
+	lastID         uint64
+	timeout        time.Duration
 	mutex          sync.Mutex
 	queueSizeGauge prometheus.Gauge
+
+	// Closed and replaced whenever references are enqueued, waking the consumers waiting in Next
+	available chan struct{}
+}
+
+type claimedReference struct {
+	jobRef   *models.JobReference
+	deadline time.Time
 }
 
-func NewJobQueue() *JobQueue {
-	queueSizeGauge := metrics.GetManager().CreateGauge("job_reference_queue_size", "The size of the job reference queue")
-	return &JobQueue{
+func NewInMemoryQueue(visibilityTimeout time.Duration) *InMemoryQueue {
+	return &InMemoryQueue{
 		items:          make([]*models.JobReference, 0),
-		queueSizeGauge: queueSizeGauge,
+		claimed:        make(map[string]claimedReference),
+		timeout:        visibilityTimeout,
+		queueSizeGauge: newQueueSizeGauge(),
+		available:      make(chan struct{}),
 	}
 }
 
-func (q *JobQueue) Enqueue(job *models.JobReference) {
+func newQueueSizeGauge() prometheus.Gauge {
+	return metrics.GetManager().CreateGauge("job_reference_queue_size", "The size of the job reference queue")
+}
+
+func (q *InMemoryQueue) Enqueue(_ context.Context, job *models.JobReference) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 	q.items = append(q.items, job)
-	q.queueSizeGauge.Set(float64(len(q.items)))
+	q.queueSizeGauge.Set(float64(q.size()))
+
+	close(q.available)
+	q.available = make(chan struct{})
+	return nil
+}
+
+// Next claims the next job reference, waiting for one to be enqueued or for a claimed one to time out
+// when the queue is empty
+func (q *InMemoryQueue) Next(ctx context.Context) (*Delivery, error) {
+	for {
+		delivery, available, deadline := q.claim(time.Now())
+		if delivery != nil {
+			return delivery, nil
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			timer = time.NewTimer(time.Until(deadline))
+			timeout = timer.C
+		}
+
+		select {
+		case <-available:
+		case <-timeout:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
 }
 
-func (q *JobQueue) Dequeue() *models.JobReference {
+// claim takes the first job reference of the queue, returning the channel closed on the next Enqueue
+// and the earliest deadline of the claimed references when the queue is empty
+func (q *InMemoryQueue) claim(now time.Time) (*Delivery, <-chan struct{}, time.Time) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	// References whose claim timed out are retried first
+	var deadline time.Time
+	for id, claimed := range q.claimed {
+		if !claimed.deadline.After(now) {
+			q.items = append([]*models.JobReference{claimed.jobRef}, q.items...)
+			delete(q.claimed, id)
+		} else if deadline.IsZero() || claimed.deadline.Before(deadline) {
+			deadline = claimed.deadline
+		}
+	}
+
 	if len(q.items) == 0 {
-		return nil
+		return nil, q.available, deadline
 	}
 
 	job := q.items[0]
 	q.items = q.items[1:]
-	q.queueSizeGauge.Set(float64(len(q.items)))
-	return job
+
+	q.lastID++
+	id := strconv.FormatUint(q.lastID, 10)
+	deadline = now.Add(q.timeout)
+	q.claimed[id] = claimedReference{jobRef: job, deadline: deadline}
+	return &Delivery{JobRef: job, ID: id, Deadline: deadline}, nil, time.Time{}
 }
 
-func (q *JobQueue) Size() int {
+func (q *InMemoryQueue) Ack(_ context.Context, delivery *Delivery) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
-	return len(q.items)
+
+	delete(q.claimed, delivery.ID)
+	q.queueSizeGauge.Set(float64(q.size()))
+	return nil
+}
+
+func (q *InMemoryQueue) Extend(_ context.Context, delivery *Delivery) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	claimed, exists := q.claimed[delivery.ID]
+	if !exists || !claimed.deadline.After(time.Now()) {
+		return ErrClaimLost
+	}
+
+	claimed.deadline = time.Now().Add(q.timeout)
+	q.claimed[delivery.ID] = claimed
+	delivery.Deadline = claimed.deadline
+	return nil
+}
+
+func (q *InMemoryQueue) Size(_ context.Context) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.size(), nil
+}
+
+func (q *InMemoryQueue) size() int {
+	return len(q.items) + len(q.claimed)
 }
 
-func (q *JobQueue) IsEmpty() bool {
-	return q.Size() == 0
+func (q *InMemoryQueue) Close() error {
+	return nil
 }
@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+func TestInMemoryQueueVisibilityTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		ack            bool
+		wantRedelivery bool
+		wantSize       int
+	}{
+		{
+			name:     "acknowledged reference is removed",
+			ack:      true,
+			wantSize: 0,
+		},
+		{
+			name:           "unacknowledged reference returns to the queue",
+			ack:            false,
+			wantRedelivery: true,
+			wantSize:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewInMemoryQueue(50 * time.Millisecond)
+			ctx := context.Background()
+
+			jobRef := &models.JobReference{ExternalID: "job-1", CompanyName: "acme", URL: "https://acme.test/jobs/1"}
+			if err := q.Enqueue(ctx, jobRef); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+
+			delivery, err := q.Next(ctx)
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+			if delivery.JobRef != jobRef {
+				t.Fatalf("Next() = %v, want %v", delivery.JobRef, jobRef)
+			}
+			if tt.ack {
+				if err := q.Ack(ctx, delivery); err != nil {
+					t.Fatalf("Ack() error = %v", err)
+				}
+			}
+
+			nextCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+
+			redelivery, err := q.Next(nextCtx)
+			if tt.wantRedelivery {
+				if err != nil {
+					t.Fatalf("Next() after timeout error = %v", err)
+				}
+				if redelivery.JobRef != jobRef || redelivery.ID == delivery.ID {
+					t.Errorf("Next() after timeout = %+v, want a new claim of %v", redelivery, jobRef)
+				}
+			} else if err == nil {
+				t.Errorf("Next() after ack = %+v, want no reference", redelivery)
+			}
+
+			if size, _ := q.Size(ctx); size != tt.wantSize {
+				t.Errorf("Size() = %d, want %d", size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestInMemoryQueueExtend(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	q := NewInMemoryQueue(timeout)
+	ctx := context.Background()
+
+	jobRef := &models.JobReference{ExternalID: "job-1", CompanyName: "acme", URL: "https://acme.test/jobs/1"}
+	if err := q.Enqueue(ctx, jobRef); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	delivery, err := q.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	deadline := delivery.Deadline
+
+	// Extended before it times out, the claim outlives its first deadline
+	time.Sleep(timeout / 2)
+	if err := q.Extend(ctx, delivery); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if !delivery.Deadline.After(deadline) {
+		t.Errorf("Extend() deadline = %v, want after %v", delivery.Deadline, deadline)
+	}
+
+	nextCtx, cancel := context.WithTimeout(ctx, timeout*3/4)
+	defer cancel()
+	if redelivery, err := q.Next(nextCtx); err == nil {
+		t.Errorf("Next() before the extended deadline = %+v, want no reference", redelivery)
+	}
+
+	// Once timed out, the claim is lost to the next consumer
+	time.Sleep(timeout)
+	if _, err := q.Next(ctx); err != nil {
+		t.Fatalf("Next() after timeout error = %v", err)
+	}
+	if err := q.Extend(ctx, delivery); !errors.Is(err, ErrClaimLost) {
+		t.Errorf("Extend() after timeout error = %v, want %v", err, ErrClaimLost)
+	}
+}
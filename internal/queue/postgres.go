@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/db"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PostgresQueue queues job references in the job_reference_queue table. A reference is claimed by
+// pushing its visible_at past the visibility timeout, in a statement that skips the rows locked by
+// the other consumers, and is deleted once acknowledged.
+type PostgresQueue struct {
+	db             *sqlx.DB
+	timeout        time.Duration
+	pollInterval   time.Duration
+	queueSizeGauge prometheus.Gauge
+}
+
+func NewPostgresQueue(config Config) *PostgresQueue {
+	return &PostgresQueue{
+		db:             db.GetDBClient().GetConnection(),
+		timeout:        config.VisibilityTimeout,
+		pollInterval:   config.PollInterval,
+		queueSizeGauge: newQueueSizeGauge(),
+	}
+}
+
+func (q *PostgresQueue) Enqueue(ctx context.Context, jobRef *models.JobReference) error {
+	payload, err := json.Marshal(jobRef)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.db.ExecContext(ctx, `INSERT INTO job_reference_queue (company_name, job_ref) VALUES ($1, $2)`,
+		jobRef.CompanyName, payload)
+	return err
+}
+
+// Next claims the oldest visible reference, polling the table while there is none
+func (q *PostgresQueue) Next(ctx context.Context) (*Delivery, error) {
+	for {
+		delivery, err := q.claim(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if delivery != nil {
+			return delivery, nil
+		}
+
+		select {
+		case <-time.After(q.pollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (q *PostgresQueue) claim(ctx context.Context) (*Delivery, error) {
+	query := `
+		UPDATE job_reference_queue
+		SET visible_at = NOW() + make_interval(secs => $1), attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM job_reference_queue
+			WHERE visible_at <= NOW()
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, job_ref, attempts`
+
+	for {
+		var id int64
+		var payload []byte
+		var attempts int
+		claimedAt := time.Now()
+		err := q.db.QueryRowxContext(ctx, query, q.timeout.Seconds()).Scan(&id, &payload, &attempts)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		delivery := &Delivery{ID: strconv.FormatInt(id, 10), Deadline: claimedAt.Add(q.timeout), attempts: attempts}
+		if err := json.Unmarshal(payload, &delivery.JobRef); err != nil {
+			logger.Error(fmt.Sprintf("Dropping invalid job reference %d from the queue: %v", id, err))
+			if err := q.Ack(ctx, delivery); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return delivery, nil
+	}
+}
+
+func (q *PostgresQueue) Ack(ctx context.Context, delivery *Delivery) error {
+	_, err := q.db.ExecContext(ctx, `DELETE FROM job_reference_queue WHERE id = $1`, delivery.ID)
+	return err
+}
+
+// Extend pushes back the visible_at of a claimed reference, unless it was claimed again since, which
+// counted another attempt
+func (q *PostgresQueue) Extend(ctx context.Context, delivery *Delivery) error {
+	query := `
+		UPDATE job_reference_queue
+		SET visible_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1 AND attempts = $2 AND visible_at > NOW()`
+
+	extendedAt := time.Now()
+	result, err := q.db.ExecContext(ctx, query, delivery.ID, delivery.attempts, q.timeout.Seconds())
+	if err != nil {
+		return err
+	}
+	extended, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if extended == 0 {
+		return ErrClaimLost
+	}
+
+	delivery.Deadline = extendedAt.Add(q.timeout)
+	return nil
+}
+
+func (q *PostgresQueue) Size(ctx context.Context) (int, error) {
+	var size int
+	if err := q.db.GetContext(ctx, &size, `SELECT COUNT(*) FROM job_reference_queue`); err != nil {
+		return 0, err
+	}
+	q.queueSizeGauge.Set(float64(size))
+	return size, nil
+}
+
+// Close leaves the shared database connection open
+func (q *PostgresQueue) Close() error {
+	return nil
+}
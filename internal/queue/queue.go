@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// Queue holds the job references waiting to be enriched. A reference taken from the queue is hidden
+// from the other consumers until it is acknowledged, and returns to the queue when it is not
+// acknowledged within the visibility timeout, so that the work claimed by a crashed worker is not lost.
+type Queue interface {
+	// Enqueue adds a job reference to the queue
+	Enqueue(ctx context.Context, jobRef *models.JobReference) error
+
+	// Next claims the next job reference, waiting for one to be enqueued when the queue is empty
+	Next(ctx context.Context) (*Delivery, error)
+
+	// Ack removes a claimed job reference from the queue once it has been processed
+	Ack(ctx context.Context, delivery *Delivery) error
+
+	// Extend pushes the deadline of a claim a visibility timeout away, for a consumer holding a job
+	// reference longer than expected. It returns ErrClaimLost when the claim already timed out.
+	Extend(ctx context.Context, delivery *Delivery) error
+
+	// Size returns the number of job references in the queue, claimed ones included
+	Size(ctx context.Context) (int, error)
+
+	// Close releases the connections of the queue
+	Close() error
+}
+
+// ErrClaimLost is returned when extending a claim that timed out, its job reference being delivered again
+var ErrClaimLost = errors.New("claim of the job reference timed out")
+
+// Delivery is a job reference claimed from a queue
+type Delivery struct {
+	JobRef *models.JobReference
+
+	// ID identifies the claim in the queue backend
+	ID string
+
+	// Deadline is when the claim times out unless the reference is acknowledged or the claim extended
+	Deadline time.Time
+
+	// attempts tells the claims of a reference apart in backends reusing its ID
+	attempts int
+}
+
+// Config holds the configuration of the job reference queue
+type Config struct {
+	// Backend is where job references are queued: "memory", "redis" or "postgres"
+	Backend string `env:"QUEUE_BACKEND" envDefault:"memory"`
+
+	// VisibilityTimeout is how long a claimed job reference is hidden from the other consumers before
+	// it returns to the queue. Claims are extended while the reference is held, halfway through the timeout.
+	VisibilityTimeout time.Duration `env:"QUEUE_VISIBILITY_TIMEOUT" envDefault:"5m"`
+
+	// PollInterval is how often the durable backends are checked for job references when the queue is empty
+	PollInterval time.Duration `env:"QUEUE_POLL_INTERVAL" envDefault:"1s"`
+
+	// Key is the Redis stream of the queue
+	Key string `env:"QUEUE_REDIS_KEY" envDefault:"bobber:job_references"`
+}
+
+func LoadConfig() Config {
+	config := Config{}
+	if err := env.Parse(&config); err != nil {
+		logger.Error("Failed to parse queue config", "error", err)
+		panic(err)
+	}
+	if config.VisibilityTimeout <= 0 {
+		panic(fmt.Errorf("QUEUE_VISIBILITY_TIMEOUT must be positive, got %v", config.VisibilityTimeout))
+	}
+	if config.PollInterval <= 0 {
+		panic(fmt.Errorf("QUEUE_POLL_INTERVAL must be positive, got %v", config.PollInterval))
+	}
+	return config
+}
+
+// New creates the queue of the configured backend
+func New(config Config) (Queue, error) {
+	switch config.Backend {
+	case "", "memory":
+		return NewInMemoryQueue(config.VisibilityTimeout), nil
+	case "redis":
+		return NewRedisQueue(config)
+	case "postgres":
+		return NewPostgresQueue(config), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q, expected memory, redis or postgres", config.Backend)
+	}
+}
@@ -0,0 +1,196 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/gkettani/bobber-the-swe/internal/cache"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisGroup is the consumer group of the enrichment workers on the stream
+const redisGroup = "enrichment"
+
+// RedisQueue queues job references in a Redis stream read by a consumer group. Claimed references stay
+// pending in the group until they are acknowledged, and are claimed again by the next consumer once
+// they have been pending for longer than the visibility timeout.
+type RedisQueue struct {
+	client         *redis.Client
+	key            string
+	consumer       string
+	timeout        time.Duration
+	pollInterval   time.Duration
+	queueSizeGauge prometheus.Gauge
+}
+
+func NewRedisQueue(config Config) (*RedisQueue, error) {
+	redisConfig := cache.RedisConfig{}
+	if err := env.Parse(&redisConfig); err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr: redisConfig.Addr,
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	// Starting from the beginning of the stream delivers the references left by a previous run
+	err := client.XGroupCreateMkStream(ctx, config.Key, redisGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		client.Close()
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	return &RedisQueue{
+		client:         client,
+		key:            config.Key,
+		consumer:       fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		timeout:        config.VisibilityTimeout,
+		pollInterval:   config.PollInterval,
+		queueSizeGauge: newQueueSizeGauge(),
+	}, nil
+}
+
+func (q *RedisQueue) Enqueue(ctx context.Context, jobRef *models.JobReference) error {
+	payload, err := json.Marshal(jobRef)
+	if err != nil {
+		return err
+	}
+
+	return q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.key,
+		Values: map[string]interface{}{"job_ref": payload},
+	}).Err()
+}
+
+// Next claims a reference whose previous claim timed out, or the next new reference of the stream
+func (q *RedisQueue) Next(ctx context.Context) (*Delivery, error) {
+	for {
+		messages, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   q.key,
+			Group:    redisGroup,
+			Consumer: q.consumer,
+			MinIdle:  q.timeout,
+			Start:    "0-0",
+			Count:    1,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		if len(messages) == 0 {
+			// Blocking for the poll interval bounds how long claims that time out wait to be retried
+			streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    redisGroup,
+				Consumer: q.consumer,
+				Streams:  []string{q.key, ">"},
+				Count:    1,
+				Block:    q.pollInterval,
+			}).Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, err
+			}
+			for _, stream := range streams {
+				messages = append(messages, stream.Messages...)
+			}
+		}
+
+		for _, message := range messages {
+			if delivery := q.decode(ctx, message); delivery != nil {
+				return delivery, nil
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// decode reads the job reference of a message, dropping the message when it is not one
+func (q *RedisQueue) decode(ctx context.Context, message redis.XMessage) *Delivery {
+	payload, _ := message.Values["job_ref"].(string)
+
+	var jobRef models.JobReference
+	if err := json.Unmarshal([]byte(payload), &jobRef); err != nil {
+		logger.Error(fmt.Sprintf("Dropping invalid job reference %s from the queue: %v", message.ID, err))
+		if err := q.Ack(ctx, &Delivery{ID: message.ID}); err != nil {
+			logger.Error("Failed to drop invalid job reference", "error", err)
+		}
+		return nil
+	}
+
+	return &Delivery{JobRef: &jobRef, ID: message.ID, Deadline: time.Now().Add(q.timeout)}
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, delivery *Delivery) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, q.key, redisGroup, delivery.ID)
+		pipe.XDel(ctx, q.key, delivery.ID)
+		return nil
+	})
+	return err
+}
+
+// extendScript resets the idle time of an entry pending for the consumer, unless it has been pending for
+// longer than the visibility timeout. The check and the claim are atomic, so that an entry claimed again
+// by another consumer in between is not taken back.
+var extendScript = redis.NewScript(`
+local pending = redis.call('XPENDING', KEYS[1], ARGV[1], ARGV[2], ARGV[2], 1)
+if #pending == 0 or pending[1][2] ~= ARGV[3] or pending[1][3] >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('XCLAIM', KEYS[1], ARGV[1], ARGV[3], 0, ARGV[2], 'JUSTID')
+return 1
+`)
+
+// Extend resets the idle time of a reference pending for this consumer, unless its claim timed out
+func (q *RedisQueue) Extend(ctx context.Context, delivery *Delivery) error {
+	extendedAt := time.Now()
+	extended, err := extendScript.Run(ctx, q.client, []string{q.key},
+		redisGroup, delivery.ID, q.consumer, q.timeout.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if extended == 0 {
+		return ErrClaimLost
+	}
+
+	delivery.Deadline = extendedAt.Add(q.timeout)
+	return nil
+}
+
+// Size returns the length of the stream, acknowledged references being deleted from it
+func (q *RedisQueue) Size(ctx context.Context) (int, error) {
+	size, err := q.client.XLen(ctx, q.key).Result()
+	if err != nil {
+		return 0, err
+	}
+	q.queueSizeGauge.Set(float64(size))
+	return int(size), nil
+}
+
+func (q *RedisQueue) Close() error {
+	return q.client.Close()
+}
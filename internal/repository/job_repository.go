@@ -5,11 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/gkettani/bobber-the-swe/internal/db"
 	"github.com/gkettani/bobber-the-swe/internal/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type JobRepository interface {
@@ -17,6 +19,37 @@ type JobRepository interface {
 	Upsert(ctx context.Context, job *models.JobDetails) error
 	BulkInsert(ctx context.Context, jobs []*models.JobDetails) error
 	FindByID(ctx context.Context, id int64) (*models.JobDetails, error)
+
+	// MarkSeen resets the missed discoveries of the jobs listed by a discovery, claiming them for its company
+	// configuration. Expired jobs listed again are reactivated, whether or not they are enriched again.
+	MarkSeen(ctx context.Context, scope DiscoveryScope, externalIDs []string) error
+	// FindMissing returns the active jobs of a company configuration not listed by its discovery
+	FindMissing(ctx context.Context, scope DiscoveryScope, externalIDs []string) ([]*TrackedJob, error)
+	// CountActive returns the number of active jobs of a company configuration
+	CountActive(ctx context.Context, scope DiscoveryScope) (int, error)
+	// RecordMissed counts a missed discovery for jobs, expiring the ones missed expireAfter times in a row
+	RecordMissed(ctx context.Context, ids []int64, expireAfter int) (int64, error)
+	// Expire expires jobs
+	Expire(ctx context.Context, ids []int64) (int64, error)
+	// ExpireByIdentity expires the job with a source, company and external ID
+	ExpireByIdentity(ctx context.Context, source, companyName, externalID string) (int64, error)
+}
+
+// DiscoveryScope identifies the jobs discovered with a company configuration. Configurations may share
+// the source and company name of their jobs, as several sitemaps of a company do, so the jobs are also
+// scoped by the registry key of the configuration which last listed them.
+type DiscoveryScope struct {
+	CompanyKey  string
+	Source      string
+	CompanyName string
+}
+
+// TrackedJob is an active job tracked for expiry
+type TrackedJob struct {
+	ID                int64  `db:"id"`
+	ExternalID        string `db:"external_id"`
+	URL               string `db:"url"`
+	MissedDiscoveries int    `db:"missed_discoveries"`
 }
 
 type jobRepository struct {
@@ -62,24 +95,89 @@ func (r *jobRepository) WithTransaction(ctx context.Context, fn func(ctx context
 	return nil
 }
 
-func (r *jobRepository) Insert(ctx context.Context, job *models.JobDetails) error {
-	query := `
-		INSERT INTO jobs (
-			title, description, company_name, location, url, external_id
-		) VALUES (
-			$1, $2, $3, $4, $5, $6
-		) RETURNING id`
-
-	err := r.db.QueryRowxContext(
-		ctx,
-		query,
+// jobColumns are the columns written when saving job details, in the order of jobValues
+var jobColumns = []string{
+	"title", "description", "company_name", "source", "company_key", "location", "url", "external_id",
+	"department", "offices", "posting_updated_at", "team", "employment_type", "workplace_type",
+	"date_posted", "valid_through", "salary_min", "salary_max", "salary_currency", "salary_unit", "hiring_organization",
+	"hash",
+}
+
+// identityColumns identify a job, external IDs being only unique within the system of a company
+var identityColumns = []string{"source", "company_name", "external_id"}
+
+// revisionColumns are the columns of a job kept in job_revisions when its content changes
+var revisionColumns = []string{
+	"title", "description", "location", "url",
+	"department", "offices", "posting_updated_at", "team", "employment_type", "workplace_type",
+	"date_posted", "valid_through", "salary_min", "salary_max", "salary_currency", "salary_unit", "hiring_organization",
+	"hash",
+}
+
+// upsertClause updates a conflicting job with the saved details, bumping updated_at when its content changed
+var upsertClause = func() string {
+	assignments := make([]string, 0, len(jobColumns)+4)
+	for _, column := range jobColumns {
+		if !slices.Contains(identityColumns, column) && column != "company_key" {
+			assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+	}
+	assignments = append(assignments,
+		// References retried from before company keys were recorded keep the configuration of the job
+		"company_key = COALESCE(NULLIF(EXCLUDED.company_key, ''), jobs.company_key)",
+		"updated_at = CASE WHEN jobs.hash IS DISTINCT FROM EXCLUDED.hash THEN NOW() ELSE jobs.updated_at END",
+		"last_seen_at = NOW()",
+		"last_scraped_at = NOW()",
+		"missed_discoveries = 0",
+		"expired_at = NULL",
+	)
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(identityColumns, ", "), strings.Join(assignments, ", "))
+}()
+
+// jobValues returns the values of jobColumns for a job
+func jobValues(job *models.JobDetails) []any {
+	return []any{
 		job.Title,
 		job.Description,
 		job.CompanyName,
+		job.Source,
+		job.CompanyKey,
 		job.Location,
 		job.URL,
 		job.ExternalID,
-	).Scan(&job.ID)
+		job.Department,
+		job.Offices,
+		job.PostingUpdatedAt,
+		job.Team,
+		job.EmploymentType,
+		job.WorkplaceType,
+		job.DatePosted,
+		job.ValidThrough,
+		job.SalaryMin,
+		job.SalaryMax,
+		job.SalaryCurrency,
+		job.SalaryUnit,
+		job.HiringOrganization,
+		job.Hash,
+	}
+}
+
+// valuesPlaceholders returns the "($1, $2, ...)" placeholders of a row of jobColumns, starting after offset
+func valuesPlaceholders(offset int) string {
+	placeholders := make([]string, len(jobColumns))
+	for i := range jobColumns {
+		placeholders[i] = fmt.Sprintf("$%d", offset+i+1)
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+func (r *jobRepository) Insert(ctx context.Context, job *models.JobDetails) error {
+	query := fmt.Sprintf(`
+		INSERT INTO jobs (%s)
+		VALUES %s
+		RETURNING id`, strings.Join(jobColumns, ", "), valuesPlaceholders(0))
+
+	err := r.db.QueryRowxContext(ctx, query, jobValues(job)...).Scan(&job.ID)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert job: %w", err)
@@ -89,31 +187,70 @@ func (r *jobRepository) Insert(ctx context.Context, job *models.JobDetails) erro
 }
 
 func (r *jobRepository) Upsert(ctx context.Context, job *models.JobDetails) error {
-	query := `
-		INSERT INTO jobs (
-			title, description, company_name, location, url, external_id
-		) VALUES (
-			$1, $2, $3, $4, $5, $6
-		) ON CONFLICT (external_id) DO UPDATE 
-		SET
-			last_seen_at = NOW()
-		RETURNING id`
-
-	err := r.db.QueryRowxContext(
-		ctx,
-		query,
-		job.Title,
-		job.Description,
-		job.CompanyName,
-		job.Location,
-		job.URL,
-		job.ExternalID,
-	).Scan(&job.ID)
+	query := fmt.Sprintf(`
+		INSERT INTO jobs (%s)
+		VALUES %s
+		%s
+		RETURNING id`, strings.Join(jobColumns, ", "), valuesPlaceholders(0), upsertClause)
+
+	return r.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		if err := prepareUpsert(ctx, tx, []*models.JobDetails{job}); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowxContext(ctx, query, jobValues(job)...).Scan(&job.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to insert or update job: %w", err)
+		}
+		return nil
+	})
+}
+
+// prepareUpsert readies the jobs for the details about to be saved. Jobs saved before sources were
+// part of their identity are claimed by the source of their details, then the current version of the
+// jobs whose content hash differs from the one of the details is stored as a revision. Jobs saved
+// before hashes were computed have no previous version to keep.
+func prepareUpsert(ctx context.Context, tx *sqlx.Tx, jobs []*models.JobDetails) error {
+	sources := make([]string, len(jobs))
+	companies := make([]string, len(jobs))
+	externalIDs := make([]string, len(jobs))
+	hashes := make([]string, len(jobs))
+	for i, job := range jobs {
+		sources[i] = job.Source
+		companies[i] = job.CompanyName
+		externalIDs[i] = job.ExternalID
+		hashes[i] = job.Hash
+	}
+	saved := `UNNEST($1::text[], $2::text[], $3::text[], $4::text[]) AS saved (source, company_name, external_id, hash)`
+
+	claim := fmt.Sprintf(`
+		UPDATE jobs
+		SET source = saved.source
+		FROM %s
+		WHERE jobs.source = '' AND saved.source <> ''
+			AND jobs.company_name = saved.company_name AND jobs.external_id = saved.external_id
+			AND NOT EXISTS (
+				SELECT 1 FROM jobs claimed
+				WHERE claimed.source = saved.source
+					AND claimed.company_name = saved.company_name AND claimed.external_id = saved.external_id
+			)`, saved)
 
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("failed to insert or update job: %w", err)
+	if _, err := tx.ExecContext(ctx, claim, pq.Array(sources), pq.Array(companies), pq.Array(externalIDs), pq.Array(hashes)); err != nil {
+		return fmt.Errorf("failed to claim jobs saved without a source: %w", err)
 	}
 
+	archive := fmt.Sprintf(`
+		INSERT INTO job_revisions (job_id, %s, valid_from)
+		SELECT jobs.id, %s, jobs.updated_at
+		FROM jobs
+		JOIN %s ON jobs.source = saved.source
+			AND jobs.company_name = saved.company_name AND jobs.external_id = saved.external_id
+		WHERE COALESCE(jobs.hash, '') <> '' AND saved.hash <> '' AND jobs.hash <> saved.hash`,
+		strings.Join(revisionColumns, ", "), "jobs."+strings.Join(revisionColumns, ", jobs."), saved)
+
+	if _, err := tx.ExecContext(ctx, archive, pq.Array(sources), pq.Array(companies), pq.Array(externalIDs), pq.Array(hashes)); err != nil {
+		return fmt.Errorf("failed to archive job revisions: %w", err)
+	}
 	return nil
 }
 
@@ -130,33 +267,22 @@ func (r *jobRepository) BulkInsert(ctx context.Context, jobs []*models.JobDetail
 			batch := jobs[i:end]
 
 			placeholders := make([]string, len(batch))
-			values := make([]any, 0, len(batch)*6)
+			values := make([]any, 0, len(batch)*len(jobColumns))
 
 			for j, job := range batch {
 				// Calculate placeholder position
-				pos := j * 6
-				placeholders[j] = fmt.Sprintf(
-					"($%d, $%d, $%d, $%d, $%d, $%d)",
-					pos+1, pos+2, pos+3, pos+4, pos+5, pos+6,
-				)
-
-				values = append(values,
-					job.Title,
-					job.Description,
-					job.CompanyName,
-					job.Location,
-					job.URL,
-					job.ExternalID,
-				)
+				placeholders[j] = valuesPlaceholders(j * len(jobColumns))
+				values = append(values, jobValues(job)...)
+			}
+
+			if err := prepareUpsert(ctx, tx, batch); err != nil {
+				return err
 			}
 
 			query := fmt.Sprintf(`
-				INSERT INTO jobs (
-					title, description, company_name, location, url, external_id
-				) VALUES %s
-				ON CONFLICT (external_id) DO UPDATE 
-				SET
-					last_seen_at = NOW()`, strings.Join(placeholders, ","))
+				INSERT INTO jobs (%s)
+				VALUES %s
+				%s`, strings.Join(jobColumns, ", "), strings.Join(placeholders, ","), upsertClause)
 
 			_, err := tx.ExecContext(ctx, query, values...)
 			if err != nil {
@@ -169,7 +295,7 @@ func (r *jobRepository) BulkInsert(ctx context.Context, jobs []*models.JobDetail
 }
 
 func (r *jobRepository) FindByID(ctx context.Context, id int64) (*models.JobDetails, error) {
-	query := `SELECT id, title, description, company_name, external_id, location, url FROM jobs WHERE id = $1`
+	query := fmt.Sprintf(`SELECT id, %s FROM jobs WHERE id = $1`, strings.Join(jobColumns, ", "))
 
 	var job models.JobDetails
 	err := r.db.GetContext(ctx, &job, query, id)
@@ -182,3 +308,90 @@ func (r *jobRepository) FindByID(ctx context.Context, id int64) (*models.JobDeta
 
 	return &job, nil
 }
+
+func (r *jobRepository) MarkSeen(ctx context.Context, scope DiscoveryScope, externalIDs []string) error {
+	query := `
+		UPDATE jobs
+		SET last_seen_at = NOW(), missed_discoveries = 0, expired_at = NULL, company_key = $1
+		WHERE source = $2 AND company_name = $3 AND external_id = ANY($4)`
+
+	if _, err := r.db.ExecContext(ctx, query, scope.CompanyKey, scope.Source, scope.CompanyName, pq.Array(externalIDs)); err != nil {
+		return fmt.Errorf("failed to mark jobs as seen: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) FindMissing(ctx context.Context, scope DiscoveryScope, externalIDs []string) ([]*TrackedJob, error) {
+	query := `
+		SELECT id, external_id, url, missed_discoveries
+		FROM jobs
+		WHERE company_key = $1 AND source = $2 AND company_name = $3
+			AND NOT (external_id = ANY($4)) AND expired_at IS NULL`
+
+	var jobs []*TrackedJob
+	err := r.db.SelectContext(ctx, &jobs, query, scope.CompanyKey, scope.Source, scope.CompanyName, pq.Array(externalIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find missing jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) CountActive(ctx context.Context, scope DiscoveryScope) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM jobs
+		WHERE company_key = $1 AND source = $2 AND company_name = $3 AND expired_at IS NULL`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, scope.CompanyKey, scope.Source, scope.CompanyName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active jobs: %w", err)
+	}
+	return count, nil
+}
+
+func (r *jobRepository) RecordMissed(ctx context.Context, ids []int64, expireAfter int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		WITH missed AS (
+			UPDATE jobs
+			SET
+				missed_discoveries = missed_discoveries + 1,
+				expired_at = CASE WHEN missed_discoveries + 1 >= $2 THEN NOW() END
+			WHERE id = ANY($1) AND expired_at IS NULL
+			RETURNING expired_at
+		)
+		SELECT COUNT(*) FROM missed WHERE expired_at IS NOT NULL`
+
+	var expired int64
+	if err := r.db.GetContext(ctx, &expired, query, pq.Array(ids), expireAfter); err != nil {
+		return 0, fmt.Errorf("failed to record missed discoveries: %w", err)
+	}
+	return expired, nil
+}
+
+func (r *jobRepository) Expire(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE jobs SET expired_at = NOW() WHERE id = ANY($1) AND expired_at IS NULL`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *jobRepository) ExpireByIdentity(ctx context.Context, source, companyName, externalID string) (int64, error) {
+	query := `
+		UPDATE jobs SET expired_at = NOW()
+		WHERE source = $1 AND company_name = $2 AND external_id = $3 AND expired_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, source, companyName, externalID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire job: %w", err)
+	}
+	return result.RowsAffected()
+}
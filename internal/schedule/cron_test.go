@@ -0,0 +1,65 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	// Wednesday
+	after := time.Date(2025, time.January, 15, 10, 17, 42, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "every minute", expr: "* * * * *", want: time.Date(2025, 1, 15, 10, 18, 0, 0, time.UTC)},
+		{name: "step", expr: "*/15 * * * *", want: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{name: "list of hours", expr: "0 6,18 * * *", want: time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC)},
+		{name: "range of weekdays", expr: "30 9 * * mon-fri", want: time.Date(2025, 1, 16, 9, 30, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", want: time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month", expr: "0 3 1 * *", want: time.Date(2025, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{name: "day of month or week", expr: "0 0 1 * fri", want: time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{name: "month name", expr: "0 0 1 jun *", want: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "step from value", expr: "5/20 * * * *", want: time.Date(2025, 1, 15, 10, 25, 0, 0, time.UTC)},
+		{name: "macro", expr: "@daily", want: time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{name: "never matching", expr: "0 0 30 2 *", want: time.Time{}},
+		{name: "too few fields", expr: "0 * * *", wantErr: true},
+		{name: "out of range", expr: "60 * * * *", wantErr: true},
+		{name: "inverted range", expr: "0 18-6 * * *", wantErr: true},
+		{name: "invalid step", expr: "*/0 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error for %q", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+
+			if got := schedule.Next(after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvery(t *testing.T) {
+	after := time.Date(2025, time.January, 15, 10, 0, 0, 0, time.UTC)
+	schedule := Every(time.Hour, 10*time.Minute)
+
+	for i := 0; i < 100; i++ {
+		next := schedule.Next(after)
+		if next.Before(after.Add(time.Hour)) || !next.Before(after.Add(70*time.Minute)) {
+			t.Fatalf("Expected the next run within the jitter of the interval, got: %v", next)
+		}
+	}
+}
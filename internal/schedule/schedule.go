@@ -0,0 +1,67 @@
+// Package schedule computes when recurring tasks run, from cron expressions or fixed intervals.
+package schedule
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Schedule computes the next run of a recurring task
+type Schedule interface {
+	// Next returns the time of the first run strictly after the given time
+	Next(after time.Time) time.Time
+
+	// String describes the schedule, for status reports
+	String() string
+}
+
+type interval struct {
+	every  time.Duration
+	jitter time.Duration
+}
+
+// Every runs a task at a fixed interval, delayed by a random duration up to jitter so that tasks
+// sharing the interval do not run in lockstep
+func Every(every, jitter time.Duration) Schedule {
+	return &interval{every: every, jitter: jitter}
+}
+
+func (s *interval) Next(after time.Time) time.Time {
+	return after.Add(s.every + randomDelay(s.jitter))
+}
+
+func (s *interval) String() string {
+	if s.jitter > 0 {
+		return fmt.Sprintf("every %v (jitter %v)", s.every, s.jitter)
+	}
+	return fmt.Sprintf("every %v", s.every)
+}
+
+type jittered struct {
+	Schedule
+	jitter time.Duration
+}
+
+// WithJitter delays the runs of a schedule by a random duration up to jitter
+func WithJitter(schedule Schedule, jitter time.Duration) Schedule {
+	if jitter <= 0 {
+		return schedule
+	}
+	return &jittered{Schedule: schedule, jitter: jitter}
+}
+
+func (s *jittered) Next(after time.Time) time.Time {
+	return s.Schedule.Next(after).Add(randomDelay(s.jitter))
+}
+
+func (s *jittered) String() string {
+	return fmt.Sprintf("%v (jitter %v)", s.Schedule, s.jitter)
+}
+
+func randomDelay(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return rand.N(jitter)
+}
@@ -5,14 +5,64 @@ import (
 	"os"
 	"strings"
 
+	"github.com/gkettani/bobber-the-swe/internal/fetcher"
+	"github.com/gkettani/bobber-the-swe/internal/jsonquery"
 	"gopkg.in/yaml.v3"
 )
 
+// Extraction strategies of a scraper
+const (
+	// StrategySelectors reads the CSS selectors, falling back on the page's JSON-LD JobPosting for empty fields
+	StrategySelectors = "selectors"
+	// StrategyJSONLD reads the page's JSON-LD JobPosting, falling back on the CSS selectors when set
+	StrategyJSONLD = "json_ld"
+	// StrategyEmbeddedJSON reads the JSON state embedded in the page, falling back on the CSS selectors when set
+	StrategyEmbeddedJSON = "embedded_json"
+)
+
 type ScraperConfig struct {
 	Name        string         `yaml:"name"`
 	URLPatterns []string       `yaml:"url_patterns"`
+	Strategy    string         `yaml:"strategy,omitempty"` // selectors (default), json_ld or embedded_json
 	Selectors   SelectorConfig `yaml:"selectors"`
 	Enabled     bool           `yaml:"enabled"`
+
+	EmbeddedJSON *EmbeddedJSONConfig `yaml:"embedded_json,omitempty"`
+}
+
+// EmbeddedJSONConfig locates the JSON state embedded in a posting page and the job fields within it.
+// Paths are JMESPath expressions evaluated against the whole JSON.
+type EmbeddedJSONConfig struct {
+	fetcher.EmbeddedJSONSource `yaml:",inline"`
+
+	TitlePath       string `yaml:"title_path"`
+	LocationPath    string `yaml:"location_path,omitempty"`
+	DescriptionPath string `yaml:"description_path,omitempty"`
+}
+
+func (c *EmbeddedJSONConfig) Validate() error {
+	if err := c.EmbeddedJSONSource.Validate(); err != nil {
+		return err
+	}
+
+	if c.TitlePath == "" {
+		return fmt.Errorf("title_path is required")
+	}
+
+	for field, path := range map[string]string{
+		"title_path":       c.TitlePath,
+		"location_path":    c.LocationPath,
+		"description_path": c.DescriptionPath,
+	} {
+		if path == "" {
+			continue
+		}
+		if err := jsonquery.Validate(path); err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+	}
+
+	return nil
 }
 
 type SelectorConfig struct {
@@ -54,6 +104,23 @@ func (c *ScraperConfig) Validate() error {
 		return fmt.Errorf("at least one URL pattern is required")
 	}
 
+	switch c.Strategy {
+	case "", StrategySelectors:
+	case StrategyJSONLD:
+		// Selectors are only a fallback when reading JSON-LD
+		return nil
+	case StrategyEmbeddedJSON:
+		if c.EmbeddedJSON == nil {
+			return fmt.Errorf("embedded_json is required for embedded_json strategy")
+		}
+		if err := c.EmbeddedJSON.Validate(); err != nil {
+			return fmt.Errorf("invalid embedded_json: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid strategy: %s", c.Strategy)
+	}
+
 	if c.Selectors.Title == "" {
 		return fmt.Errorf("title selector is required")
 	}
@@ -0,0 +1,261 @@
+package scraper
+
+import (
+	"encoding/json"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+)
+
+// jobPosting is the subset of a schema.org JobPosting (https://schema.org/JobPosting) that is extracted.
+// Properties that may be a single value or a list, a text or an object are kept raw.
+type jobPosting struct {
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	DatePosted         string          `json:"datePosted"`
+	ValidThrough       string          `json:"validThrough"`
+	EmploymentType     json.RawMessage `json:"employmentType"`
+	HiringOrganization json.RawMessage `json:"hiringOrganization"`
+	JobLocation        json.RawMessage `json:"jobLocation"`
+	JobLocationType    string          `json:"jobLocationType"`
+	BaseSalary         *struct {
+		Currency string          `json:"currency"`
+		Value    json.RawMessage `json:"value"`
+	} `json:"baseSalary"`
+}
+
+type jsonLDPlace struct {
+	Name    string          `json:"name"`
+	Address json.RawMessage `json:"address"`
+}
+
+type jsonLDAddress struct {
+	AddressLocality string          `json:"addressLocality"`
+	AddressRegion   string          `json:"addressRegion"`
+	AddressCountry  json.RawMessage `json:"addressCountry"`
+}
+
+type jsonLDQuantitativeValue struct {
+	Value    *float64 `json:"value"`
+	MinValue *float64 `json:"minValue"`
+	MaxValue *float64 `json:"maxValue"`
+	UnitText string   `json:"unitText"`
+}
+
+// extractJobPosting returns the details of the first JobPosting found in the JSON-LD scripts of a page,
+// or nil when the page has none
+func extractJobPosting(doc *goquery.Document) *models.JobDetails {
+	var details *models.JobDetails
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		var data interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+
+		raw := findJobPosting(data)
+		if raw == nil {
+			return true
+		}
+
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return true
+		}
+
+		var posting jobPosting
+		if err := json.Unmarshal(encoded, &posting); err != nil {
+			return true
+		}
+
+		details = posting.toJobDetails()
+		return false
+	})
+
+	return details
+}
+
+// findJobPosting looks for a JobPosting object in a JSON-LD document, which may be a single object,
+// a list of objects or a @graph
+func findJobPosting(data interface{}) map[string]interface{} {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if posting := findJobPosting(item); posting != nil {
+				return posting
+			}
+		}
+	case map[string]interface{}:
+		if isJobPostingType(v["@type"]) {
+			return v
+		}
+		if graph, exists := v["@graph"]; exists {
+			return findJobPosting(graph)
+		}
+	}
+	return nil
+}
+
+func isJobPostingType(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == "JobPosting"
+	case []interface{}:
+		for _, item := range v {
+			if isJobPostingType(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *jobPosting) toJobDetails() *models.JobDetails {
+	details := &models.JobDetails{
+		Title:              html.UnescapeString(strings.TrimSpace(p.Title)),
+		Description:        strings.TrimSpace(p.Description),
+		EmploymentType:     strings.Join(textList(p.EmploymentType), ", "),
+		HiringOrganization: organizationName(p.HiringOrganization),
+		DatePosted:         parseSchemaDate(p.DatePosted),
+		ValidThrough:       parseSchemaDate(p.ValidThrough),
+	}
+
+	// Descriptions are often HTML-escaped inside the JSON
+	if strings.Contains(details.Description, "&lt;") {
+		details.Description = html.UnescapeString(details.Description)
+	}
+
+	locations := placeNames(p.JobLocation)
+	if len(locations) > 0 {
+		details.Location = locations[0]
+		details.Offices = strings.Join(locations, "; ")
+	}
+
+	if strings.EqualFold(p.JobLocationType, "TELECOMMUTE") {
+		details.WorkplaceType = "remote"
+		if details.Location == "" {
+			details.Location = "Remote"
+		}
+	}
+
+	if p.BaseSalary != nil {
+		details.SalaryCurrency = p.BaseSalary.Currency
+
+		var value jsonLDQuantitativeValue
+		if err := json.Unmarshal(p.BaseSalary.Value, &value); err == nil {
+			details.SalaryUnit = value.UnitText
+			details.SalaryMin = value.MinValue
+			details.SalaryMax = value.MaxValue
+			if value.Value != nil && details.SalaryMin == nil && details.SalaryMax == nil {
+				details.SalaryMin = value.Value
+				details.SalaryMax = value.Value
+			}
+		} else if amount, err := strconv.ParseFloat(strings.Trim(string(p.BaseSalary.Value), `"`), 64); err == nil {
+			details.SalaryMin = &amount
+			details.SalaryMax = &amount
+		}
+	}
+
+	return details
+}
+
+// textList reads a property that may be a text or a list of texts
+func textList(raw json.RawMessage) []string {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		if single = strings.TrimSpace(single); single != "" {
+			return []string{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var values []string
+		for _, value := range list {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+// organizationName reads a hiringOrganization, either an Organization or its name
+func organizationName(raw json.RawMessage) string {
+	var organization struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &organization); err == nil {
+		return strings.TrimSpace(organization.Name)
+	}
+
+	names := textList(raw)
+	if len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// placeNames reads a jobLocation, either a Place or a list of places, as "locality, region, country" texts
+func placeNames(raw json.RawMessage) []string {
+	var places []jsonLDPlace
+	if err := json.Unmarshal(raw, &places); err != nil {
+		var place jsonLDPlace
+		if err := json.Unmarshal(raw, &place); err != nil {
+			return nil
+		}
+		places = []jsonLDPlace{place}
+	}
+
+	var names []string
+	for _, place := range places {
+		if name := addressText(place.Address); name != "" {
+			names = append(names, name)
+		} else if place.Name != "" {
+			names = append(names, strings.TrimSpace(place.Name))
+		}
+	}
+	return names
+}
+
+// addressText formats a PostalAddress, which may also be given as plain text
+func addressText(raw json.RawMessage) string {
+	var address jsonLDAddress
+	if err := json.Unmarshal(raw, &address); err != nil {
+		return strings.Join(textList(raw), ", ")
+	}
+
+	country := organizationName(address.AddressCountry)
+
+	var parts []string
+	for _, part := range []string{address.AddressLocality, address.AddressRegion, country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// schemaDateLayouts are the ISO 8601 formats found in datePosted and validThrough
+var schemaDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parseSchemaDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range schemaDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
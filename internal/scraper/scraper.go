@@ -2,12 +2,17 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/gkettani/bobber-the-swe/internal/cache"
+	"github.com/gkettani/bobber-the-swe/internal/fetcher"
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/jsonquery"
 	"github.com/gkettani/bobber-the-swe/internal/logger"
 	"github.com/gkettani/bobber-the-swe/internal/metrics"
 	"github.com/gkettani/bobber-the-swe/internal/models"
@@ -18,6 +23,9 @@ type Scraper struct {
 	httpClient *http.Client
 	companies  map[string]ScraperConfig
 	metrics    *ScraperMetrics
+
+	// ETag and Last-Modified of the postings, to request them conditionally
+	validators cache.Manager
 }
 
 type ScraperMetrics struct {
@@ -48,16 +56,10 @@ func NewScraper() *Scraper {
 	}
 
 	return &Scraper{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		},
-		companies: make(map[string]ScraperConfig),
-		metrics:   scraperMetrics,
+		httpClient: httpclient.GetClient(),
+		companies:  make(map[string]ScraperConfig),
+		metrics:    scraperMetrics,
+		validators: httpclient.NewValidatorCache(),
 	}
 }
 
@@ -80,26 +82,61 @@ func (s *Scraper) LoadFromConfig(configPath string) error {
 	return nil
 }
 
-func (s *Scraper) Scrape(ctx context.Context, jobReference *models.JobReference) (*models.JobDetails, error) {
-	companyConfig := s.findCompanyByURL(jobReference.URL)
-	if companyConfig == nil {
-		return nil, fmt.Errorf("no scraper configuration found for URL: %s", jobReference.URL)
+// Scrape enriches a job reference. The validators of the responses are only stored by the commit function
+// returned, to be called once the job was saved: a job that failed to be saved is requested in full on its
+// retry rather than skipped as not modified.
+func (s *Scraper) Scrape(ctx context.Context, jobReference *models.JobReference) (*models.JobDetails, func(), error) {
+	var companyName string
+	var attempt func(ctx context.Context) (*models.JobDetails, error)
+
+	if adapter, exists := fetcher.ATSAdapterForReference(jobReference); exists {
+		// Postings from applicant tracking systems are read from their API rather than scraped
+		companyName = jobReference.CompanyName
+		attempt = func(ctx context.Context) (*models.JobDetails, error) {
+			return adapter.Enrich(ctx, s.httpClient, jobReference)
+		}
+	} else {
+		companyConfig := s.findCompanyByURL(jobReference.URL)
+		if companyConfig == nil {
+			return nil, nil, fmt.Errorf("no scraper configuration found for URL: %s", jobReference.URL)
+		}
+		companyName = companyConfig.Name
+		attempt = func(ctx context.Context) (*models.JobDetails, error) {
+			return s.attemptScrape(ctx, jobReference, companyConfig)
+		}
 	}
 
 	start := time.Now()
 	defer func() {
-		s.metrics.scrapeDuration.WithLabelValues(companyConfig.Name).Set(time.Since(start).Seconds())
+		s.metrics.scrapeDuration.WithLabelValues(companyName).Set(time.Since(start).Seconds())
 	}()
 
-	s.metrics.scrapeTotal.WithLabelValues(companyConfig.Name).Inc()
+	s.metrics.scrapeTotal.WithLabelValues(companyName).Inc()
 
-	job, err := s.scrapeWithConfig(ctx, jobReference, companyConfig)
+	// Transient failures are retried by the HTTP client, so a failed attempt is final
+	revalidation := httpclient.NewRevalidation(s.validators)
+	job, err := attempt(revalidation.Context(ctx))
+	if errors.Is(err, httpclient.ErrNotModified) {
+		return nil, nil, err
+	}
 	if err != nil {
-		s.metrics.scrapeErrors.WithLabelValues(companyConfig.Name, "scrape_error").Inc()
-		return nil, fmt.Errorf("failed to scrape job from %s: %w", companyConfig.Name, err)
+		errorType := httpclient.ErrorType(err)
+		if errorType == "" {
+			errorType = "scrape_error"
+		}
+		s.metrics.scrapeErrors.WithLabelValues(companyName, errorType).Inc()
+		return nil, nil, fmt.Errorf("failed to scrape job from %s: %w", companyName, err)
 	}
 
-	return job, nil
+	return job, revalidation.Commit, nil
+}
+
+// CanScrape checks if a job reference can be enriched, either from its ATS or with a scraper configuration
+func (s *Scraper) CanScrape(jobReference *models.JobReference) bool {
+	if _, exists := fetcher.ATSAdapterForReference(jobReference); exists {
+		return true
+	}
+	return s.findCompanyByURL(jobReference.URL) != nil
 }
 
 func (s *Scraper) GetRegisteredCompanies() []string {
@@ -119,42 +156,61 @@ func (s *Scraper) findCompanyByURL(url string) *ScraperConfig {
 	return nil
 }
 
-func (s *Scraper) scrapeWithConfig(ctx context.Context, jobReference *models.JobReference, config *ScraperConfig) (*models.JobDetails, error) {
-	var lastErr error
-	maxRetries := 3
-	baseDelay := 1 * time.Second
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			delay := time.Duration(attempt) * baseDelay
-			logger.Info(fmt.Sprintf("Retrying scrape for %s (attempt %d/%d) after %v", jobReference.URL, attempt+1, maxRetries, delay))
-
-			select {
-			case <-time.After(delay):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
-		}
+// extractEmbeddedJSON reads the job fields from the JSON state embedded in a posting page
+func extractEmbeddedJSON(doc *goquery.Document, config *EmbeddedJSONConfig) (*models.JobDetails, error) {
+	data, err := config.Extract(doc)
+	if err != nil {
+		return nil, err
+	}
 
-		job, err := s.attemptScrape(ctx, jobReference, config)
-		if err == nil {
-			return job, nil
+	job := &models.JobDetails{}
+	for _, field := range []struct {
+		path  string
+		value *string
+	}{
+		{config.TitlePath, &job.Title},
+		{config.LocationPath, &job.Location},
+		{config.DescriptionPath, &job.Description},
+	} {
+		if field.path == "" {
+			continue
 		}
+		if *field.value, err = jsonquery.SearchString(field.path, data); err != nil {
+			return nil, err
+		}
+	}
 
-		lastErr = err
+	return job, nil
+}
 
-		// Don't retry on certain errors
-		if strings.Contains(err.Error(), "no scraper configuration") ||
-			strings.Contains(err.Error(), "could not extract job title") ||
-			strings.Contains(err.Error(), "status code: 404") ||
-			strings.Contains(err.Error(), "status code: 403") {
-			break
+// fillEmptyFields completes the fields of a job left empty by the primary extraction strategy
+func fillEmptyFields(job, fallback *models.JobDetails) {
+	fillString := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
 		}
-
-		logger.Info(fmt.Sprintf("Scrape attempt %d failed for %s: %v", attempt+1, jobReference.URL, err))
 	}
 
-	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+	fillString(&job.Title, fallback.Title)
+	fillString(&job.Location, fallback.Location)
+	fillString(&job.Description, fallback.Description)
+	fillString(&job.Offices, fallback.Offices)
+	fillString(&job.EmploymentType, fallback.EmploymentType)
+	fillString(&job.WorkplaceType, fallback.WorkplaceType)
+	fillString(&job.SalaryCurrency, fallback.SalaryCurrency)
+	fillString(&job.SalaryUnit, fallback.SalaryUnit)
+	fillString(&job.HiringOrganization, fallback.HiringOrganization)
+
+	if job.DatePosted == nil {
+		job.DatePosted = fallback.DatePosted
+	}
+	if job.ValidThrough == nil {
+		job.ValidThrough = fallback.ValidThrough
+	}
+	if job.SalaryMin == nil && job.SalaryMax == nil {
+		job.SalaryMin = fallback.SalaryMin
+		job.SalaryMax = fallback.SalaryMax
+	}
 }
 
 func (s *Scraper) attemptScrape(ctx context.Context, jobReference *models.JobReference, config *ScraperConfig) (*models.JobDetails, error) {
@@ -169,8 +225,8 @@ func (s *Scraper) attemptScrape(ctx context.Context, jobReference *models.JobRef
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-OK status code: %d", resp.StatusCode)
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
@@ -178,27 +234,61 @@ func (s *Scraper) attemptScrape(ctx context.Context, jobReference *models.JobRef
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	title := strings.TrimSpace(doc.Find(config.Selectors.Title).First().Text())
-	location := strings.TrimSpace(doc.Find(config.Selectors.Location).First().Text())
+	selected := &models.JobDetails{}
+	if config.Selectors.Title != "" {
+		selected.Title = strings.TrimSpace(doc.Find(config.Selectors.Title).First().Text())
+	}
+	if config.Selectors.Location != "" {
+		selected.Location = strings.TrimSpace(doc.Find(config.Selectors.Location).First().Text())
+	}
+	if config.Selectors.Description != "" {
+		description, err := doc.Find(config.Selectors.Description).Html()
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract description: %w", err)
+		}
+		selected.Description = strings.TrimSpace(description)
+	}
 
-	description, err := doc.Find(config.Selectors.Description).Html()
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract description: %w", err)
+	posting := extractJobPosting(doc)
+
+	job := selected
+	switch config.Strategy {
+	case StrategyEmbeddedJSON:
+		embedded, err := extractEmbeddedJSON(doc, config.EmbeddedJSON)
+		if err != nil {
+			return nil, err
+		}
+		job = embedded
+		fillEmptyFields(job, selected)
+	case StrategyJSONLD:
+		if posting != nil {
+			job = posting
+			fillEmptyFields(job, selected)
+		}
+	}
+	if posting != nil && job != posting {
+		fillEmptyFields(job, posting)
 	}
-	description = strings.TrimSpace(description)
 
-	if title == "" {
-		return nil, fmt.Errorf("could not extract job title using selector: %s", config.Selectors.Title)
+	// The title captured at discovery time stands in for a missing one
+	if job.Title == "" {
+		job.Title = jobReference.Title
 	}
 
-	job := &models.JobDetails{
-		ExternalID:  jobReference.ExternalID,
-		CompanyName: config.Name,
-		URL:         jobReference.URL,
-		Title:       title,
-		Location:    location,
-		Description: description,
+	if job.Title == "" {
+		switch {
+		case config.Strategy == StrategyEmbeddedJSON:
+			return nil, fmt.Errorf("could not extract job title using path: %s", config.EmbeddedJSON.TitlePath)
+		case config.Strategy == StrategyJSONLD && config.Selectors.Title == "":
+			return nil, fmt.Errorf("could not extract job title from JSON-LD JobPosting")
+		}
+		return nil, fmt.Errorf("could not extract job title using selector: %s", config.Selectors.Title)
 	}
 
+	job.ExternalID = jobReference.ExternalID
+	job.CompanyName = jobReference.CompanyName
+	job.Source = jobReference.Source
+	job.URL = jobReference.URL
+
 	return job, nil
 }
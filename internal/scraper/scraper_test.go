@@ -1,9 +1,28 @@
 package scraper
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/fetcher"
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/models"
 )
 
+// TestMain disables the rate limits and robots.txt lookups of the shared HTTP client, the test servers
+// being local and answering every path
+func TestMain(m *testing.M) {
+	os.Setenv("HTTP_HOST_RATE_LIMIT", "0")
+	os.Setenv("HTTP_RESPECT_ROBOTS", "false")
+	os.Exit(m.Run())
+}
+
 func TestScraperConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -60,6 +79,29 @@ func TestScraperConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "json_ld strategy without selectors",
+			config: ScraperConfig{
+				Name:        "Test Company",
+				URLPatterns: []string{"test.com"},
+				Strategy:    StrategyJSONLD,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid strategy",
+			config: ScraperConfig{
+				Name:        "Test Company",
+				URLPatterns: []string{"test.com"},
+				Strategy:    "microdata",
+				Selectors: SelectorConfig{
+					Title:       "h1",
+					Location:    ".location",
+					Description: ".description",
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -135,3 +177,213 @@ func TestScraper_GetRegisteredCompanies(t *testing.T) {
 		t.Error("Expected 'Test Company 2' to be in registered companies")
 	}
 }
+
+func TestScraper_Scrape_PrefilledReference(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div class="description"><p>Scraped description</p></div></body></html>`)
+	}))
+	defer server.Close()
+
+	scraper := NewScraper()
+	scraper.companies["test"] = ScraperConfig{
+		Name:        "Test Company",
+		URLPatterns: []string{server.URL},
+		Selectors: SelectorConfig{
+			Title:       "h1",
+			Location:    ".location",
+			Description: ".description",
+		},
+		Enabled: true,
+	}
+
+	jobReference := &models.JobReference{
+		ExternalID:  "1",
+		URL:         server.URL + "/jobs/1",
+		CompanyName: "Test Company",
+		Title:       "Listed Title",
+		Location:    "Paris",
+	}
+
+	job, _, err := scraper.Scrape(context.Background(), jobReference)
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+
+	if job.Title != "Listed Title" {
+		t.Errorf("Expected the listed title to stand in for the missing one, got: %q", job.Title)
+	}
+
+	if job.Description != "<p>Scraped description</p>" {
+		t.Errorf("Expected the scraped description, got: %q", job.Description)
+	}
+}
+
+const jobPostingPage = `<html><head>
+<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Organization", "name": "Acme"}</script>
+<script type="application/ld+json">
+{
+	"@context": "https://schema.org",
+	"@graph": [{
+		"@type": "JobPosting",
+		"title": "Data Engineer",
+		"description": "&lt;p&gt;Own our pipelines.&lt;/p&gt;",
+		"datePosted": "2025-03-01",
+		"validThrough": "2025-04-30T23:59:59+02:00",
+		"employmentType": ["FULL_TIME", "CONTRACTOR"],
+		"hiringOrganization": {"@type": "Organization", "name": "Acme Corp"},
+		"jobLocation": [
+			{"@type": "Place", "address": {"@type": "PostalAddress", "addressLocality": "Paris", "addressCountry": "FR"}},
+			{"@type": "Place", "address": {"addressLocality": "Berlin", "addressCountry": {"@type": "Country", "name": "DE"}}}
+		],
+		"baseSalary": {"@type": "MonetaryAmount", "currency": "EUR", "value": {"@type": "QuantitativeValue", "minValue": 60000, "maxValue": 75000, "unitText": "YEAR"}}
+	}]
+}
+</script></head>
+<body><h1>Senior Data Engineer</h1><div class="description"></div></body></html>`
+
+func TestScraper_Scrape_JSONLD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, jobPostingPage)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		strategy  string
+		selectors SelectorConfig
+		wantTitle string
+	}{
+		{
+			name:      "fallback for empty selectors",
+			selectors: SelectorConfig{Title: "h1", Location: ".location", Description: ".description"},
+			wantTitle: "Senior Data Engineer",
+		},
+		{
+			name:      "primary strategy",
+			strategy:  StrategyJSONLD,
+			wantTitle: "Data Engineer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scraper := NewScraper()
+			scraper.companies["test"] = ScraperConfig{
+				Name:        "Test Company",
+				URLPatterns: []string{server.URL},
+				Strategy:    tt.strategy,
+				Selectors:   tt.selectors,
+				Enabled:     true,
+			}
+
+			job, _, err := scraper.Scrape(context.Background(), &models.JobReference{
+				ExternalID:  "1",
+				URL:         server.URL + "/jobs/1",
+				CompanyName: "Test Company",
+			})
+			if err != nil {
+				t.Fatalf("Scrape() error = %v", err)
+			}
+
+			if job.Title != tt.wantTitle {
+				t.Errorf("Expected title %q, got: %q", tt.wantTitle, job.Title)
+			}
+			if job.Location != "Paris, FR" || job.Offices != "Paris, FR; Berlin, DE" {
+				t.Errorf("Unexpected location %q and offices %q", job.Location, job.Offices)
+			}
+			if job.Description != "<p>Own our pipelines.</p>" {
+				t.Errorf("Unexpected description: %q", job.Description)
+			}
+			if job.EmploymentType != "FULL_TIME, CONTRACTOR" || job.HiringOrganization != "Acme Corp" {
+				t.Errorf("Unexpected employment type %q and organization %q", job.EmploymentType, job.HiringOrganization)
+			}
+			if job.DatePosted == nil || !job.DatePosted.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("Unexpected date posted: %v", job.DatePosted)
+			}
+			if job.ValidThrough == nil || job.ValidThrough.UTC().Day() != 30 {
+				t.Errorf("Unexpected valid through: %v", job.ValidThrough)
+			}
+			if job.SalaryMin == nil || *job.SalaryMin != 60000 || job.SalaryMax == nil || *job.SalaryMax != 75000 ||
+				job.SalaryCurrency != "EUR" || job.SalaryUnit != "YEAR" {
+				t.Errorf("Unexpected salary: %v-%v %s per %s", job.SalaryMin, job.SalaryMax, job.SalaryCurrency, job.SalaryUnit)
+			}
+		})
+	}
+}
+
+func TestScraper_Scrape_EmbeddedJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div id="__next"></div>
+<script id="__NEXT_DATA__" type="application/json">{"props": {"pageProps": {"job": {
+	"title": "Platform Engineer",
+	"locations": [{"name": "Remote, Europe"}],
+	"descriptionHtml": "<p>Run the platform.</p>"
+}}}}</script></body></html>`)
+	}))
+	defer server.Close()
+
+	scraper := NewScraper()
+	scraper.companies["test"] = ScraperConfig{
+		Name:        "Test Company",
+		URLPatterns: []string{server.URL},
+		Strategy:    StrategyEmbeddedJSON,
+		EmbeddedJSON: &EmbeddedJSONConfig{
+			EmbeddedJSONSource: fetcher.EmbeddedJSONSource{ScriptSelector: "script#__NEXT_DATA__"},
+			TitlePath:          "props.pageProps.job.title",
+			LocationPath:       "props.pageProps.job.locations[0].name",
+			DescriptionPath:    "props.pageProps.job.descriptionHtml",
+		},
+		Enabled: true,
+	}
+
+	job, _, err := scraper.Scrape(context.Background(), &models.JobReference{
+		ExternalID:  "1",
+		URL:         server.URL + "/jobs/1",
+		CompanyName: "Test Company",
+	})
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+
+	if job.Title != "Platform Engineer" || job.Location != "Remote, Europe" || job.Description != "<p>Run the platform.</p>" {
+		t.Errorf("Unexpected job details: %+v", job)
+	}
+}
+
+func TestScraper_Scrape_CommitValidators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `<html><body><h1>Backend Engineer</h1></body></html>`)
+	}))
+	defer server.Close()
+
+	scraper := NewScraper()
+	scraper.companies["test"] = ScraperConfig{
+		Name:        "Test Company",
+		URLPatterns: []string{server.URL},
+		Selectors:   SelectorConfig{Title: "h1"},
+		Enabled:     true,
+	}
+	jobReference := &models.JobReference{ExternalID: "1", URL: server.URL + "/jobs/1", CompanyName: "Test Company"}
+
+	// The job failing to be saved, the validators are not committed and the retry gets the full posting
+	if _, _, err := scraper.Scrape(context.Background(), jobReference); err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	job, commit, err := scraper.Scrape(context.Background(), jobReference)
+	if err != nil {
+		t.Fatalf("Expected the posting to be requested in full before commit, got: %v", err)
+	}
+	if job.Title != "Backend Engineer" {
+		t.Errorf("Unexpected title: %q", job.Title)
+	}
+
+	commit()
+	if _, _, err := scraper.Scrape(context.Background(), jobReference); !errors.Is(err, httpclient.ErrNotModified) {
+		t.Errorf("Expected ErrNotModified once the validators were committed, got: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Package secrets resolves the secret references of configuration values, so that credentials are kept
+// out of the committed configuration files.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+)
+
+// filePrefix marks references read from a file, like Docker and Kubernetes secrets
+const filePrefix = "file:"
+
+// Resolve replaces the ${NAME} references of a value with the NAME environment variable, and the
+// ${file:/path} references with the content of the file. $${ is kept as a literal ${.
+// The resolved secrets are registered with the logger so that they are redacted from the logs.
+func Resolve(value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var resolved strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			resolved.WriteString(rest)
+			break
+		}
+
+		if start > 0 && rest[start-1] == '$' {
+			resolved.WriteString(rest[:start-1])
+			resolved.WriteString("${")
+			rest = rest[start+2:]
+			continue
+		}
+
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated secret reference in %q", rest[start:])
+		}
+		reference := rest[start+2 : start+end]
+
+		secret, err := lookup(reference)
+		if err != nil {
+			return "", err
+		}
+		logger.RegisterSecret(secret)
+
+		resolved.WriteString(rest[:start])
+		resolved.WriteString(secret)
+		rest = rest[start+end+1:]
+	}
+
+	return resolved.String(), nil
+}
+
+func lookup(reference string) (string, error) {
+	if path, isFile := strings.CutPrefix(reference, filePrefix); isFile {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if reference == "" {
+		return "", fmt.Errorf("empty secret reference")
+	}
+	secret, exists := os.LookupEnv(reference)
+	if !exists {
+		return "", fmt.Errorf("environment variable %s is not set", reference)
+	}
+	return secret, nil
+}
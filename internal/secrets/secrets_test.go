@@ -0,0 +1,51 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("BOBBER_TEST_TOKEN", "s3cr3t-token")
+
+	secretFile := filepath.Join(t.TempDir(), "client_secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "no reference", value: "application/json", want: "application/json"},
+		{name: "environment variable", value: "Bearer ${BOBBER_TEST_TOKEN}", want: "Bearer s3cr3t-token"},
+		{name: "file", value: "${file:" + secretFile + "}", want: "from-file"},
+		{name: "several references", value: `{"key":"${BOBBER_TEST_TOKEN}","secret":"${file:` + secretFile + `}"}`, want: `{"key":"s3cr3t-token","secret":"from-file"}`},
+		{name: "escaped reference", value: "$${BOBBER_TEST_TOKEN}", want: "${BOBBER_TEST_TOKEN}"},
+		{name: "dollar without brace", value: "query($first: Int)", want: "query($first: Int)"},
+		{name: "unset variable", value: "${BOBBER_TEST_UNSET}", wantErr: true},
+		{name: "missing file", value: "${file:/nonexistent/secret}", wantErr: true},
+		{name: "unterminated reference", value: "${BOBBER_TEST_TOKEN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got: %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
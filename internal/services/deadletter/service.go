@@ -0,0 +1,190 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/gkettani/bobber-the-swe/internal/db"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/metrics"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/services"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// retryLease is how long a claimed dead letter waits before being retried again when the outcome of its
+// retry is never recorded, e.g. because the process stopped
+const retryLease = time.Hour
+
+// Config holds the configuration of the dead-letter store
+type Config struct {
+	// Number of failed processings after which a job reference is no longer retried
+	MaxAttempts int `env:"DEAD_LETTER_MAX_ATTEMPTS" envDefault:"5"`
+
+	// Delay before the first retry, doubled on each failure up to the max delay
+	RetryBaseDelay time.Duration `env:"DEAD_LETTER_RETRY_BASE_DELAY" envDefault:"5m"`
+	RetryMaxDelay  time.Duration `env:"DEAD_LETTER_RETRY_MAX_DELAY" envDefault:"6h"`
+}
+
+func LoadConfig() Config {
+	config := Config{}
+	if err := env.Parse(&config); err != nil {
+		logger.Error("Failed to parse dead-letter config", "error", err)
+		panic(err)
+	}
+	return config
+}
+
+// service implements DeadLetterService with the dead_letters table
+type service struct {
+	config  Config
+	db      *sqlx.DB
+	records *prometheus.CounterVec
+}
+
+// NewDeadLetterService creates a new dead-letter service
+func NewDeadLetterService(config Config) services.DeadLetterService {
+	return &service{
+		config: config,
+		db:     db.GetDBClient().GetConnection(),
+		records: metrics.GetManager().CreateCounterVec(
+			"dead_letters_total",
+			"Total number of failed job reference processings stored in the dead-letter store",
+			[]string{"company", "error_class"},
+		),
+	}
+}
+
+// Record stores the failure of a job reference. The retry delay doubles with each attempt, from the
+// base delay up to the max delay.
+func (s *service) Record(ctx context.Context, jobRef *models.JobReference, errorClass, lastError string) error {
+	stored := *jobRef
+	stored.Retries = 0
+	payload, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO dead_letters (source, company_name, external_id, url, job_ref, error_class, last_error, next_retry_at, company_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7,
+			CASE WHEN $8 > 1 THEN NOW() + make_interval(secs => $9) END, $11)
+		ON CONFLICT (source, company_name, external_id) DO UPDATE SET
+			company_key = COALESCE(NULLIF(EXCLUDED.company_key, ''), dead_letters.company_key),
+			url = EXCLUDED.url,
+			job_ref = EXCLUDED.job_ref,
+			error_class = EXCLUDED.error_class,
+			last_error = EXCLUDED.last_error,
+			attempts = dead_letters.attempts + 1,
+			last_failed_at = NOW(),
+			next_retry_at = CASE WHEN dead_letters.attempts + 1 < $8
+				THEN NOW() + make_interval(secs => LEAST($9 * POWER(2, dead_letters.attempts), $10))
+			END`
+
+	_, err = s.db.ExecContext(ctx, query,
+		jobRef.Source, jobRef.CompanyName, jobRef.ExternalID, jobRef.URL, payload, errorClass, lastError,
+		s.config.MaxAttempts, s.config.RetryBaseDelay.Seconds(), s.config.RetryMaxDelay.Seconds(), jobRef.CompanyKey)
+	if err != nil {
+		return fmt.Errorf("failed to record dead letter: %w", err)
+	}
+
+	s.records.WithLabelValues(jobRef.CompanyName, errorClass).Inc()
+	return nil
+}
+
+// Resolve removes the dead letter of a job reference processed successfully
+func (s *service) Resolve(ctx context.Context, jobRef *models.JobReference) error {
+	query := `DELETE FROM dead_letters WHERE source = $1 AND company_name = $2 AND external_id = $3`
+
+	if _, err := s.db.ExecContext(ctx, query, jobRef.Source, jobRef.CompanyName, jobRef.ExternalID); err != nil {
+		return fmt.Errorf("failed to resolve dead letter: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue returns the job references whose retry is due, in the order they were due
+func (s *service) ClaimDue(ctx context.Context, limit int) ([]*models.JobReference, error) {
+	query := `
+		UPDATE dead_letters
+		SET next_retry_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM dead_letters
+			WHERE next_retry_at <= NOW()
+			ORDER BY next_retry_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, job_ref, attempts, company_key`
+
+	rows, err := s.db.QueryxContext(ctx, query, limit, retryLease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var jobRefs []*models.JobReference
+	for rows.Next() {
+		var id int64
+		var payload []byte
+		var attempts int
+		var companyKey string
+		if err := rows.Scan(&id, &payload, &attempts, &companyKey); err != nil {
+			return nil, fmt.Errorf("failed to read dead letter: %w", err)
+		}
+
+		var jobRef models.JobReference
+		if err := json.Unmarshal(payload, &jobRef); err != nil {
+			logger.Error(fmt.Sprintf("Skipping invalid job reference of dead letter %d: %v", id, err))
+			continue
+		}
+		jobRef.Retries = attempts
+		// References recorded before company keys take the one of their job
+		if jobRef.CompanyKey == "" {
+			jobRef.CompanyKey = companyKey
+		}
+		jobRefs = append(jobRefs, &jobRef)
+	}
+	return jobRefs, rows.Err()
+}
+
+// List returns the dead letters of a company configuration, most recent failure first
+func (s *service) List(ctx context.Context, companyKey string) ([]*models.DeadLetter, error) {
+	query := `
+		SELECT id, source, company_name, company_key, external_id, url, error_class, last_error, attempts,
+		       first_failed_at, last_failed_at, next_retry_at
+		FROM dead_letters
+		WHERE company_key = $1
+		ORDER BY last_failed_at DESC, id DESC`
+
+	deadLetters := []*models.DeadLetter{}
+	if err := s.db.SelectContext(ctx, &deadLetters, query, companyKey); err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return deadLetters, nil
+}
+
+// Retry makes dead letters of a company configuration due now, including the ones that ran out of attempts
+func (s *service) Retry(ctx context.Context, companyKey string, id int64) (int64, error) {
+	query := `UPDATE dead_letters SET next_retry_at = NOW() WHERE company_key = $1 AND ($2 = 0 OR id = $2)`
+
+	result, err := s.db.ExecContext(ctx, query, companyKey, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retry dead letters: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Purge deletes dead letters of a company configuration
+func (s *service) Purge(ctx context.Context, companyKey string, id int64) (int64, error) {
+	query := `DELETE FROM dead_letters WHERE company_key = $1 AND ($2 = 0 OR id = $2)`
+
+	result, err := s.db.ExecContext(ctx, query, companyKey, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+	return result.RowsAffected()
+}
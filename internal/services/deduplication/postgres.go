@@ -0,0 +1,74 @@
+package deduplication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/db"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// queryTimeout bounds the deduplication queries, which are made on every job reference
+const queryTimeout = 5 * time.Second
+
+// postgresService implements DeduplicationService with the last_scraped_at column of the jobs table,
+// set whenever a job is saved. A reference with no job yet, or whose job was scraped more than the TTL
+// ago, is not processed.
+type postgresService struct {
+	db  *sqlx.DB
+	ttl time.Duration
+}
+
+func newPostgresService(ttl time.Duration) *postgresService {
+	return &postgresService{
+		db:  db.GetDBClient().GetConnection(),
+		ttl: ttl,
+	}
+}
+
+// IsProcessed checks if the job of a reference was scraped within the TTL
+func (s *postgresService) IsProcessed(jobRef *models.JobReference) bool {
+	if jobRef == nil || jobRef.ExternalID == "" {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM jobs
+			WHERE source = $1 AND company_name = $2 AND external_id = $3
+				AND last_scraped_at > NOW() - make_interval(secs => $4)
+		)`
+
+	var processed bool
+	err := s.db.GetContext(ctx, &processed, query, jobRef.Source, jobRef.CompanyName, jobRef.ExternalID, s.ttl.Seconds())
+	if err != nil {
+		// Enriching the reference again is cheaper than missing a change
+		logger.Error(fmt.Sprintf("Failed to check if job reference %s was processed: %v", jobRef.ExternalID, err))
+		return false
+	}
+	return processed
+}
+
+// MarkAsProcessed sets the last scrape time of the job of a reference, the jobs saved for the first time
+// getting theirs when inserted
+func (s *postgresService) MarkAsProcessed(jobRef *models.JobReference) {
+	if jobRef == nil || jobRef.ExternalID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	query := `UPDATE jobs SET last_scraped_at = NOW() WHERE source = $1 AND company_name = $2 AND external_id = $3`
+
+	_, err := s.db.ExecContext(ctx, query, jobRef.Source, jobRef.CompanyName, jobRef.ExternalID)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to mark job reference %s as processed: %v", jobRef.ExternalID, err))
+	}
+}
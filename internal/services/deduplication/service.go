@@ -1,20 +1,61 @@
 package deduplication
 
 import (
+	"time"
+
+	"github.com/caarlos0/env/v11"
 	"github.com/gkettani/bobber-the-swe/internal/cache"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
 	"github.com/gkettani/bobber-the-swe/internal/models"
 	"github.com/gkettani/bobber-the-swe/internal/services"
 )
 
+// keyPrefix namespaces the processed job references in caches shared with other components
+const keyPrefix = "dedup:"
+
+// Config holds the configuration of the deduplication
+type Config struct {
+	// Backend is where processed job references are remembered: "memory", "redis" or "postgres"
+	Backend string `env:"DEDUP_BACKEND" envDefault:"memory"`
+
+	// TTL is how long a processed job reference is skipped, after which it is enriched again so that
+	// changes and expirations of its posting are picked up
+	TTL time.Duration `env:"DEDUP_TTL" envDefault:"24h"`
+}
+
+func LoadConfig() Config {
+	config := Config{}
+	if err := env.Parse(&config); err != nil {
+		logger.Error("Failed to parse deduplication config", "error", err)
+		panic(err)
+	}
+	return config
+}
+
 // service implements DeduplicationService using the existing cache
 type service struct {
 	cache cache.Manager
 }
 
-// NewDeduplicationService creates a new deduplication service
-func NewDeduplicationService() services.DeduplicationService {
+// NewDeduplicationService creates a new deduplication service of the configured backend. The Redis
+// backend falls back to memory when Redis cannot be reached.
+func NewDeduplicationService(config Config) services.DeduplicationService {
+	switch config.Backend {
+	case "postgres":
+		return newPostgresService(config.TTL)
+	case "redis":
+		redisCache, err := cache.NewRedisCache()
+		if err == nil {
+			return &service{cache: redisCache.WithTTL(config.TTL)}
+		}
+		logger.Error("Failed to connect to Redis, deduplicating job references in memory", "error", err)
+	case "", "memory":
+	default:
+		logger.Warn("Unknown deduplication backend, deduplicating job references in memory", "backend", config.Backend)
+	}
+
 	return &service{
-		cache: cache.NewInMemoryCache(),
+		cache: cache.NewInMemoryCache().WithTTL(config.TTL),
 	}
 }
 
@@ -23,7 +64,7 @@ func (s *service) IsProcessed(jobRef *models.JobReference) bool {
 	if jobRef == nil || jobRef.ExternalID == "" {
 		return false
 	}
-	return s.cache.Exists(jobRef.ExternalID)
+	return s.cache.Exists(keyPrefix + jobRef.Key())
 }
 
 // MarkAsProcessed marks a job reference as processed
@@ -31,5 +72,5 @@ func (s *service) MarkAsProcessed(jobRef *models.JobReference) {
 	if jobRef == nil || jobRef.ExternalID == "" {
 		return
 	}
-	s.cache.Set(jobRef.ExternalID, jobRef.ExternalID)
+	s.cache.Set(keyPrefix+jobRef.Key(), jobRef.ExternalID)
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/gkettani/bobber-the-swe/internal/fetcher"
 	"github.com/gkettani/bobber-the-swe/internal/logger"
 	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/schedule"
 	"github.com/gkettani/bobber-the-swe/internal/services"
 )
 
@@ -28,47 +29,13 @@ func NewJobDiscoveryService(configPath string) (services.JobDiscoveryService, er
 	}, nil
 }
 
-// DiscoverJobs discovers job references from all configured companies
-func (s *service) DiscoverJobs(ctx context.Context) (map[string][]*models.JobReference, error) {
-	allJobListings, err := s.fetcher.FetchAllJobs()
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch job listings: %w", err)
-	}
-
-	result := make(map[string][]*models.JobReference)
-	for companyName, jobListings := range allJobListings {
-		jobReferences := make([]*models.JobReference, 0, len(jobListings))
-		for _, jobListing := range jobListings {
-			jobRef := &models.JobReference{
-				URL:         jobListing.URL,
-				ExternalID:  jobListing.ExternalID,
-				CompanyName: companyName,
-			}
-			jobReferences = append(jobReferences, jobRef)
-		}
-		result[companyName] = jobReferences
-	}
-
-	return result, nil
-}
-
 // DiscoverJobsForCompany discovers job references for a specific company
 func (s *service) DiscoverJobsForCompany(ctx context.Context, companyName string) ([]*models.JobReference, error) {
-	jobListings, err := s.fetcher.FetchJobs(companyName)
+	jobReferences, err := s.fetcher.FetchJobs(ctx, companyName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch jobs for company %s: %w", companyName, err)
 	}
 
-	jobReferences := make([]*models.JobReference, 0, len(jobListings))
-	for _, jobListing := range jobListings {
-		jobRef := &models.JobReference{
-			URL:         jobListing.URL,
-			ExternalID:  jobListing.ExternalID,
-			CompanyName: companyName,
-		}
-		jobReferences = append(jobReferences, jobRef)
-	}
-
 	return jobReferences, nil
 }
 
@@ -76,3 +43,13 @@ func (s *service) DiscoverJobsForCompany(ctx context.Context, companyName string
 func (s *service) GetRegisteredCompanies() []string {
 	return s.fetcher.GetRegisteredCompanies()
 }
+
+// GetSchedule returns when a company is discovered, nil when it follows the default discovery interval
+func (s *service) GetSchedule(companyName string) schedule.Schedule {
+	return s.fetcher.GetSchedule(companyName)
+}
+
+// IsTruncated reports whether the last discovery of a company reached max_pages before listing every posting
+func (s *service) IsTruncated(companyName string) bool {
+	return s.fetcher.IsTruncated(companyName)
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 
 	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/metrics"
 	"github.com/gkettani/bobber-the-swe/internal/models"
 	"github.com/gkettani/bobber-the-swe/internal/scraper"
 	"github.com/gkettani/bobber-the-swe/internal/services"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // service implements JobEnrichmentService using the existing scraper
 type service struct {
 	scraper *scraper.Scraper
+	skipped *prometheus.CounterVec
 }
 
 // NewJobEnrichmentService creates a new job enrichment service
@@ -26,22 +29,38 @@ func NewJobEnrichmentService(configPath string) (services.JobEnrichmentService,
 
 	return &service{
 		scraper: jobScraper,
+		skipped: metrics.GetManager().CreateCounterVec(
+			"enrichment_skipped_total",
+			"Total number of job references complete at discovery time, enriched without scraping",
+			[]string{"company"},
+		),
 	}, nil
 }
 
 // EnrichJobReference scrapes full job details from a job reference
-func (s *service) EnrichJobReference(ctx context.Context, jobRef *models.JobReference) (*models.JobDetails, error) {
+func (s *service) EnrichJobReference(ctx context.Context, jobRef *models.JobReference) (*models.JobDetails, func(), error) {
 	if !jobRef.IsValid() {
-		return nil, fmt.Errorf("invalid job reference: missing required fields")
+		return nil, nil, fmt.Errorf("invalid job reference: missing required fields")
+	}
+
+	// The source already listed everything needed, no need to fetch the posting. References with a title
+	// and no scraper configuration, as listed by feeds, are saved with what was discovered.
+	if jobRef.IsComplete() || (jobRef.Title != "" && !s.scraper.CanScrape(jobRef)) {
+		s.skipped.WithLabelValues(jobRef.CompanyName).Inc()
+		jobDetails := jobRef.ToJobDetails()
+		jobDetails.Hash = jobDetails.ContentHash()
+		return jobDetails, func() {}, nil
 	}
 
 	// Use the existing scraper directly
-	jobDetails, err := s.scraper.Scrape(ctx, jobRef)
+	jobDetails, commit, err := s.scraper.Scrape(ctx, jobRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to enrich job reference %s: %w", jobRef.ExternalID, err)
+		return nil, nil, fmt.Errorf("failed to enrich job reference %s: %w", jobRef.ExternalID, err)
 	}
 
-	return jobDetails, nil
+	jobDetails.FillFrom(jobRef)
+	jobDetails.Hash = jobDetails.ContentHash()
+	return jobDetails, commit, nil
 }
 
 // GetSupportedCompanies returns list of companies supported for enrichment
@@ -0,0 +1,176 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/gkettani/bobber-the-swe/internal/db"
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/metrics"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/repository"
+	"github.com/gkettani/bobber-the-swe/internal/services"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config holds the configuration of the expiry detection
+type Config struct {
+	// Number of discoveries in a row a job must be missing from to be expired
+	MissedDiscoveries int `env:"EXPIRY_MISSED_DISCOVERIES" envDefault:"3"`
+
+	// Share of the active jobs of a company past which a discovery missing them is considered broken
+	// rather than the jobs closed, no miss being counted
+	MaxMissingRatio float64 `env:"EXPIRY_MAX_MISSING_RATIO" envDefault:"0.5"`
+
+	// Number of newly missing postings re-checked per discovery, a posting not found being expired at once
+	MaxRechecks int `env:"EXPIRY_MAX_RECHECKS" envDefault:"20"`
+}
+
+func LoadConfig() Config {
+	config := Config{}
+	if err := env.Parse(&config); err != nil {
+		logger.Error("Failed to parse expiry config", "error", err)
+		panic(err)
+	}
+	return config
+}
+
+// service implements JobExpiryService using the job repository
+type service struct {
+	config     Config
+	repository repository.JobRepository
+	httpClient *http.Client
+	expired    *prometheus.CounterVec
+	skipped    *prometheus.CounterVec
+}
+
+// NewJobExpiryService creates a new job expiry service
+func NewJobExpiryService(config Config) services.JobExpiryService {
+	metricsManager := metrics.GetManager()
+
+	return &service{
+		config:     config,
+		repository: repository.NewJobRepository(db.GetDBClient(), 0),
+		httpClient: httpclient.GetClient(),
+		expired: metricsManager.CreateCounterVec(
+			"jobs_expired_total",
+			"Total number of jobs expired, by company and reason",
+			[]string{"company", "reason"},
+		),
+		skipped: metricsManager.CreateCounterVec(
+			"expiry_skipped_total",
+			"Total number of discoveries not used to detect expired jobs, by company and reason",
+			[]string{"company", "reason"},
+		),
+	}
+}
+
+// ReconcileDiscovery expires the active jobs of a company missing from its discovery. Jobs are looked up
+// by the source and company name of the references, which their rows are saved with, among the ones last
+// listed by the configuration of the company, so that configurations sharing a name (e.g. one per
+// sitemap) do not expire each other's jobs. A job is expired
+// when its posting is not found on re-check, or once it has been missing from enough discoveries in a
+// row. An empty discovery, or one missing most of the active jobs, is more likely broken than every
+// posting closed, so it never expires anything.
+func (s *service) ReconcileDiscovery(ctx context.Context, companyName string, jobRefs []*models.JobReference) (int64, error) {
+	if len(jobRefs) == 0 {
+		s.skipped.WithLabelValues(companyName, "empty").Inc()
+		logger.Warn(fmt.Sprintf("Discovery of %s listed no job, skipping expiry detection", companyName))
+		return 0, nil
+	}
+
+	externalIDs := make([]string, len(jobRefs))
+	for i, jobRef := range jobRefs {
+		externalIDs[i] = jobRef.ExternalID
+	}
+
+	scope := repository.DiscoveryScope{
+		CompanyKey:  companyName,
+		Source:      jobRefs[0].Source,
+		CompanyName: jobRefs[0].CompanyName,
+	}
+	if err := s.repository.MarkSeen(ctx, scope, externalIDs); err != nil {
+		return 0, err
+	}
+
+	missing, err := s.repository.FindMissing(ctx, scope, externalIDs)
+	if err != nil || len(missing) == 0 {
+		return 0, err
+	}
+
+	active, err := s.repository.CountActive(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+	if float64(len(missing)) > float64(active)*s.config.MaxMissingRatio {
+		s.skipped.WithLabelValues(companyName, "too_many_missing").Inc()
+		logger.Warn(fmt.Sprintf("Discovery of %s is missing %d of its %d active jobs, skipping expiry detection",
+			companyName, len(missing), active))
+		return 0, nil
+	}
+
+	var notFound, stillMissing []int64
+	rechecks := 0
+	for _, job := range missing {
+		// Postings are re-checked when they first go missing, the others waiting for the threshold
+		if job.MissedDiscoveries == 0 && rechecks < s.config.MaxRechecks {
+			rechecks++
+			if s.isNotFound(ctx, job.URL) {
+				notFound = append(notFound, job.ID)
+				continue
+			}
+		}
+		stillMissing = append(stillMissing, job.ID)
+	}
+
+	expiredNotFound, err := s.repository.Expire(ctx, notFound)
+	if err != nil {
+		return 0, err
+	}
+	s.expired.WithLabelValues(companyName, "not_found").Add(float64(expiredNotFound))
+
+	expiredMissing, err := s.repository.RecordMissed(ctx, stillMissing, s.config.MissedDiscoveries)
+	if err != nil {
+		return expiredNotFound, err
+	}
+	s.expired.WithLabelValues(companyName, "missing").Add(float64(expiredMissing))
+
+	if expired := expiredNotFound + expiredMissing; expired > 0 {
+		logger.Info(fmt.Sprintf("Expired %d jobs of %s: %d not found, %d missing from %d discoveries",
+			expired, companyName, expiredNotFound, expiredMissing, s.config.MissedDiscoveries))
+	}
+	return expiredNotFound + expiredMissing, nil
+}
+
+// isNotFound requests a posting, reporting whether its page is gone
+func (s *service) isNotFound(ctx context.Context, url string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Failed to re-check missing job posting %s: %v", url, err))
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return errors.Is(httpclient.CheckResponse(resp), httpclient.ErrNotFound)
+}
+
+// ExpireJob expires the job of a reference whose posting was not found
+func (s *service) ExpireJob(ctx context.Context, jobRef *models.JobReference) error {
+	expired, err := s.repository.ExpireByIdentity(ctx, jobRef.Source, jobRef.CompanyName, jobRef.ExternalID)
+	if err != nil {
+		return err
+	}
+	s.expired.WithLabelValues(jobRef.CompanyName, "not_found").Add(float64(expired))
+	return nil
+}
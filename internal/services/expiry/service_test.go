@@ -0,0 +1,235 @@
+package expiry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/fetcher"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestMain disables the rate limits and robots.txt lookups of the shared HTTP client, the test servers
+// being local and answering every path
+func TestMain(m *testing.M) {
+	os.Setenv("HTTP_HOST_RATE_LIMIT", "0")
+	os.Setenv("HTTP_RESPECT_ROBOTS", "false")
+	os.Exit(m.Run())
+}
+
+// trackedRow is a job row as stored by the job repository
+type trackedRow struct {
+	models.JobDetails
+	missed  int
+	expired bool
+}
+
+// fakeJobRepository keeps job rows in memory, scoping them as the queries of the job repository do
+type fakeJobRepository struct {
+	repository.JobRepository
+	rows []*trackedRow
+}
+
+func (r *fakeJobRepository) MarkSeen(ctx context.Context, scope repository.DiscoveryScope, externalIDs []string) error {
+	for _, row := range r.rows {
+		if row.Source == scope.Source && row.CompanyName == scope.CompanyName && slices.Contains(externalIDs, row.ExternalID) {
+			row.missed = 0
+			row.expired = false
+			row.CompanyKey = scope.CompanyKey
+		}
+	}
+	return nil
+}
+
+func (r *fakeJobRepository) inScope(row *trackedRow, scope repository.DiscoveryScope) bool {
+	return !row.expired && row.CompanyKey == scope.CompanyKey && row.Source == scope.Source && row.CompanyName == scope.CompanyName
+}
+
+func (r *fakeJobRepository) FindMissing(ctx context.Context, scope repository.DiscoveryScope, externalIDs []string) ([]*repository.TrackedJob, error) {
+	var missing []*repository.TrackedJob
+	for _, row := range r.rows {
+		if r.inScope(row, scope) && !slices.Contains(externalIDs, row.ExternalID) {
+			missing = append(missing, &repository.TrackedJob{ID: row.ID, ExternalID: row.ExternalID, URL: row.URL, MissedDiscoveries: row.missed})
+		}
+	}
+	return missing, nil
+}
+
+func (r *fakeJobRepository) CountActive(ctx context.Context, scope repository.DiscoveryScope) (int, error) {
+	count := 0
+	for _, row := range r.rows {
+		if r.inScope(row, scope) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeJobRepository) RecordMissed(ctx context.Context, ids []int64, expireAfter int) (int64, error) {
+	var expired int64
+	for _, row := range r.rows {
+		if !row.expired && slices.Contains(ids, row.ID) {
+			row.missed++
+			if row.missed >= expireAfter {
+				row.expired = true
+				expired++
+			}
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeJobRepository) Expire(ctx context.Context, ids []int64) (int64, error) {
+	var expired int64
+	for _, row := range r.rows {
+		if !row.expired && slices.Contains(ids, row.ID) {
+			row.expired = true
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeJobRepository) row(externalID string) *trackedRow {
+	for _, row := range r.rows {
+		if row.ExternalID == externalID {
+			return row
+		}
+	}
+	return nil
+}
+
+func newTestService(repo repository.JobRepository) *service {
+	return &service{
+		config:     Config{MissedDiscoveries: 2, MaxMissingRatio: 0.5},
+		repository: repo,
+		expired:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_jobs_expired_total"}, []string{"company", "reason"}),
+		skipped:    prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_expiry_skipped_total"}, []string{"company", "reason"}),
+	}
+}
+
+func TestService_ReconcileDiscovery_CompanyKey(t *testing.T) {
+	// Each sitemap of the company lists its own postings, the configurations sharing the company name
+	sitemaps := map[string][]string{
+		"/sitemap1.xml": {"R-1", "R-2", "R-3"},
+		"/sitemap2.xml": {"R-10", "R-11"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+		for _, id := range sitemaps[r.URL.Path] {
+			fmt.Fprintf(w, `<url><loc>https://careers.acme.com/job/%s/</loc></url>`, id)
+		}
+		fmt.Fprint(w, `</urlset>`)
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "companies.yaml")
+	config := fmt.Sprintf(`companies:
+  acme1:
+    name: "Acme"
+    fetch_type: "sitemap"
+    url: "%[1]s/sitemap1.xml"
+    id_pattern: "/job/(R-\\d+)/"
+    enabled: true
+  acme2:
+    name: "Acme"
+    fetch_type: "sitemap"
+    url: "%[1]s/sitemap2.xml"
+    id_pattern: "/job/(R-\\d+)/"
+    enabled: true
+`, server.URL)
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	jobFetcher := fetcher.NewJobFetcher()
+	if err := jobFetcher.LoadFromConfig(configPath); err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+
+	// Jobs saved before company keys were recorded are claimed by the discovery listing them, R-4 being
+	// closed since the previous discovery of the first sitemap
+	repo := &fakeJobRepository{}
+	for i, id := range []string{"R-1", "R-2", "R-3", "R-4", "R-10", "R-11"} {
+		row := &trackedRow{JobDetails: models.JobDetails{ID: int64(i + 1), CompanyName: "Acme", ExternalID: id}}
+		if id == "R-4" {
+			row.CompanyKey = "acme1"
+		}
+		repo.rows = append(repo.rows, row)
+	}
+	svc := newTestService(repo)
+
+	for _, companyKey := range []string{"acme1", "acme2"} {
+		jobRefs, err := jobFetcher.FetchJobs(context.Background(), companyKey)
+		if err != nil {
+			t.Fatalf("FetchJobs(%s) error = %v", companyKey, err)
+		}
+
+		if _, err := svc.ReconcileDiscovery(context.Background(), companyKey, jobRefs); err != nil {
+			t.Fatalf("ReconcileDiscovery(%s) error = %v", companyKey, err)
+		}
+	}
+
+	for id, wantKey := range map[string]string{"R-1": "acme1", "R-3": "acme1", "R-10": "acme2", "R-11": "acme2"} {
+		if row := repo.row(id); row.CompanyKey != wantKey || row.missed != 0 || row.expired {
+			t.Errorf("Job %s: company key %q, missed %d, expired %v; want %q, listed", id, row.CompanyKey, row.missed, row.expired, wantKey)
+		}
+	}
+	if row := repo.row("R-4"); row.missed != 1 {
+		t.Errorf("Expected job R-4 missing from its configuration's discovery, missed = %d", row.missed)
+	}
+
+	// A second discovery without R-4 expires it, the other configuration's jobs being left alone
+	for _, companyKey := range []string{"acme2", "acme1"} {
+		jobRefs, err := jobFetcher.FetchJobs(context.Background(), companyKey)
+		if err != nil {
+			t.Fatalf("FetchJobs(%s) error = %v", companyKey, err)
+		}
+
+		expired, err := svc.ReconcileDiscovery(context.Background(), companyKey, jobRefs)
+		if err != nil {
+			t.Fatalf("ReconcileDiscovery(%s) error = %v", companyKey, err)
+		}
+		if want := map[string]int64{"acme1": 1, "acme2": 0}[companyKey]; expired != want {
+			t.Errorf("ReconcileDiscovery(%s) expired %d jobs, want %d", companyKey, expired, want)
+		}
+	}
+	for _, row := range repo.rows {
+		if row.expired != (row.ExternalID == "R-4") {
+			t.Errorf("Job %s expired = %v", row.ExternalID, row.expired)
+		}
+	}
+}
+
+func TestService_ReconcileDiscovery_Reappeared(t *testing.T) {
+	jobRefs := []*models.JobReference{
+		{ExternalID: "R-1", CompanyName: "Acme", Source: "greenhouse", URL: "https://boards.acme.test/jobs/R-1"},
+		{ExternalID: "R-2", CompanyName: "Acme", Source: "greenhouse", URL: "https://boards.acme.test/jobs/R-2"},
+	}
+
+	// R-2 was expired after a transient miss, and is listed again without being enriched again
+	repo := &fakeJobRepository{rows: []*trackedRow{
+		{JobDetails: models.JobDetails{ID: 1, CompanyKey: "acme", Source: "greenhouse", CompanyName: "Acme", ExternalID: "R-1"}},
+		{JobDetails: models.JobDetails{ID: 2, CompanyKey: "acme", Source: "greenhouse", CompanyName: "Acme", ExternalID: "R-2"}, missed: 2, expired: true},
+	}}
+	svc := newTestService(repo)
+
+	expired, err := svc.ReconcileDiscovery(context.Background(), "acme", jobRefs)
+	if err != nil {
+		t.Fatalf("ReconcileDiscovery() error = %v", err)
+	}
+	if expired != 0 {
+		t.Errorf("ReconcileDiscovery() expired %d jobs, want none", expired)
+	}
+
+	if row := repo.row("R-2"); row.expired || row.missed != 0 {
+		t.Errorf("Job R-2: missed %d, expired %v; want it reactivated", row.missed, row.expired)
+	}
+}
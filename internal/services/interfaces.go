@@ -4,24 +4,29 @@ import (
 	"context"
 
 	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/schedule"
 )
 
 // JobDiscoveryService finds job references from company career pages
 type JobDiscoveryService interface {
-	// DiscoverJobs discovers job references from all configured companies
-	DiscoverJobs(ctx context.Context) (map[string][]*models.JobReference, error)
-
 	// DiscoverJobsForCompany discovers job references for a specific company
 	DiscoverJobsForCompany(ctx context.Context, companyName string) ([]*models.JobReference, error)
 
 	// GetRegisteredCompanies returns list of companies available for discovery
 	GetRegisteredCompanies() []string
+
+	// GetSchedule returns when a company is discovered, nil when it follows the default discovery interval
+	GetSchedule(companyName string) schedule.Schedule
+
+	// IsTruncated reports whether the last discovery of a company reached max_pages before listing every posting
+	IsTruncated(companyName string) bool
 }
 
 // JobEnrichmentService enriches job references with full details
 type JobEnrichmentService interface {
-	// EnrichJobReference scrapes full job details from a job reference
-	EnrichJobReference(ctx context.Context, jobRef *models.JobReference) (*models.JobDetails, error)
+	// EnrichJobReference scrapes full job details from a job reference. The commit function returned is
+	// called once the job was saved, for the posting to be requested conditionally next time.
+	EnrichJobReference(ctx context.Context, jobRef *models.JobReference) (jobDetails *models.JobDetails, commit func(), err error)
 
 	// GetSupportedCompanies returns list of companies supported for enrichment
 	GetSupportedCompanies() []string
@@ -36,6 +41,40 @@ type JobPersistenceService interface {
 	SaveJobDetailsBatch(ctx context.Context, jobDetails []*models.JobDetails) error
 }
 
+// JobExpiryService expires the jobs no longer posted
+type JobExpiryService interface {
+	// ReconcileDiscovery compares the job references of a complete discovery of a company, by its registry
+	// key, with its active jobs, expiring the ones missing from too many discoveries in a row or no longer
+	// found on re-check. It returns the number of expired jobs.
+	ReconcileDiscovery(ctx context.Context, companyName string, jobRefs []*models.JobReference) (int64, error)
+
+	// ExpireJob expires the job of a reference whose posting was not found
+	ExpireJob(ctx context.Context, jobRef *models.JobReference) error
+}
+
+// DeadLetterService keeps the job references whose processing failed, retrying them with backoff
+type DeadLetterService interface {
+	// Record stores the failure of a job reference, scheduling its next retry unless it ran out of attempts
+	Record(ctx context.Context, jobRef *models.JobReference, errorClass, lastError string) error
+
+	// Resolve removes the dead letter of a job reference processed successfully
+	Resolve(ctx context.Context, jobRef *models.JobReference) error
+
+	// ClaimDue returns up to limit job references whose retry is due, postponing their next retry until
+	// the outcome of this one is recorded
+	ClaimDue(ctx context.Context, limit int) ([]*models.JobReference, error)
+
+	// List returns the dead letters of a company configuration, by its registry key, most recent failure first.
+	// Configurations sharing a company name (e.g. one per sitemap) each have their own dead letters.
+	List(ctx context.Context, companyKey string) ([]*models.DeadLetter, error)
+
+	// Retry makes a dead letter of a company configuration due now, or all of them when id is 0
+	Retry(ctx context.Context, companyKey string, id int64) (int64, error)
+
+	// Purge deletes a dead letter of a company configuration, or all of them when id is 0
+	Purge(ctx context.Context, companyKey string, id int64) (int64, error)
+}
+
 // DeduplicationService handles duplicate detection
 type DeduplicationService interface {
 	// IsProcessed checks if a job reference has already been processed
@@ -53,6 +92,9 @@ type JobQueryService interface {
 	// GetJobByID retrieves a specific job by ID
 	GetJobByID(ctx context.Context, id int64) (*models.JobDetails, error)
 
+	// GetJobHistory retrieves the changes of a job, most recent first
+	GetJobHistory(ctx context.Context, id int64) (*models.JobHistory, error)
+
 	// SearchJobs performs full-text search on jobs
 	SearchJobs(ctx context.Context, query string, pagination *models.Pagination) (*models.JobList, error)
 
@@ -2,27 +2,47 @@ package orchestration
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/caarlos0/env/v11"
+	"github.com/gkettani/bobber-the-swe/internal/httpclient"
 	"github.com/gkettani/bobber-the-swe/internal/logger"
 	"github.com/gkettani/bobber-the-swe/internal/models"
 	"github.com/gkettani/bobber-the-swe/internal/queue"
+	"github.com/gkettani/bobber-the-swe/internal/schedule"
 	"github.com/gkettani/bobber-the-swe/internal/services"
 )
 
 // Config holds the configuration for the orchestrator
 type Config struct {
-	DiscoveryInterval time.Duration
-	ProcessingDelay   time.Duration
+	// Schedule of the companies without one in their configuration
+	DiscoveryInterval time.Duration `env:"DISCOVERY_INTERVAL" envDefault:"1h"`
+	DiscoveryJitter   time.Duration `env:"DISCOVERY_JITTER" envDefault:"5m"`
+
+	// Number of enrichment workers, and how many postings of a company and of a host they enrich at
+	// once, 0 disabling the cap
+	EnrichmentWorkers  int `env:"ENRICHMENT_WORKERS" envDefault:"8"`
+	CompanyConcurrency int `env:"ENRICHMENT_COMPANY_CONCURRENCY" envDefault:"2"`
+	HostConcurrency    int `env:"ENRICHMENT_HOST_CONCURRENCY" envDefault:"4"`
+
+	// How often the dead letters due for a retry are enqueued, and how many at most each time
+	DeadLetterPollInterval time.Duration `env:"DEAD_LETTER_POLL_INTERVAL" envDefault:"1m"`
+	DeadLetterBatchSize    int           `env:"DEAD_LETTER_BATCH_SIZE" envDefault:"100"`
 }
 
-// DefaultConfig returns a default configuration
-func DefaultConfig() Config {
-	return Config{
-		DiscoveryInterval: 1 * time.Hour,
-		ProcessingDelay:   100 * time.Millisecond,
+func LoadConfig() Config {
+	config := Config{}
+	if err := env.Parse(&config); err != nil {
+		logger.Error("Failed to parse orchestrator config", "error", err)
+		panic(err)
 	}
+	if config.DeadLetterPollInterval <= 0 {
+		panic(fmt.Errorf("DEAD_LETTER_POLL_INTERVAL must be positive, got %v", config.DeadLetterPollInterval))
+	}
+	return config
 }
 
 // Orchestrator coordinates the entire job processing pipeline
@@ -32,12 +52,17 @@ type Orchestrator struct {
 	enrichmentService    services.JobEnrichmentService
 	persistenceService   services.JobPersistenceService
 	deduplicationService services.DeduplicationService
-	queue                *queue.JobQueue
+	expiryService        services.JobExpiryService
+	deadLetterService    services.DeadLetterService
+	queue                queue.Queue
+	scheduler            *scheduler
+	pool                 *enrichmentPool
 	stopChan             chan struct{}
 
-	// Pipeline metrics and status
+	// Pipeline metrics and status, updated by concurrent discoveries
 	startTime time.Time
 	metrics   models.ProcessingMetrics
+	metricsMu sync.Mutex
 }
 
 // NewOrchestrator creates a new pipeline orchestrator
@@ -47,27 +72,37 @@ func NewOrchestrator(
 	enrichmentService services.JobEnrichmentService,
 	persistenceService services.JobPersistenceService,
 	deduplicationService services.DeduplicationService,
+	expiryService services.JobExpiryService,
+	deadLetterService services.DeadLetterService,
+	jobQueue queue.Queue,
 ) *Orchestrator {
-	return &Orchestrator{
+	o := &Orchestrator{
 		config:               config,
 		discoveryService:     discoveryService,
 		enrichmentService:    enrichmentService,
 		persistenceService:   persistenceService,
 		deduplicationService: deduplicationService,
-		queue:                queue.NewJobQueue(),
+		expiryService:        expiryService,
+		deadLetterService:    deadLetterService,
+		queue:                jobQueue,
+		scheduler:            newScheduler(discoveryService, schedule.Every(config.DiscoveryInterval, config.DiscoveryJitter), time.Now()),
 		stopChan:             make(chan struct{}),
 		startTime:            time.Now(),
 		metrics:              models.ProcessingMetrics{},
 	}
+	o.pool = newEnrichmentPool(config, o.queue, o.processJob)
+	return o
 }
 
 // Start begins the orchestrated job processing pipeline
 func (o *Orchestrator) Start(ctx context.Context) error {
 	logger.Info("Starting job processing pipeline")
 
-	go o.runDiscoveryWorker(ctx)
+	go o.runScheduler(ctx)
+
+	go o.runEnrichmentPool(ctx)
 
-	go o.runEnrichmentWorker(ctx)
+	go o.runDeadLetterRetries(ctx)
 
 	logger.Info("Job processing pipeline started successfully")
 	return nil
@@ -80,91 +115,169 @@ func (o *Orchestrator) Stop() error {
 	return nil
 }
 
-// runDiscoveryWorker runs the job discovery process periodically
-func (o *Orchestrator) runDiscoveryWorker(ctx context.Context) {
-	ticker := time.NewTicker(o.config.DiscoveryInterval)
-	defer ticker.Stop()
+// runScheduler triggers the discovery of each company when its schedule is due
+func (o *Orchestrator) runScheduler(ctx context.Context) {
+	for {
+		for _, companyName := range o.scheduler.due(time.Now()) {
+			go o.runDiscovery(ctx, companyName)
+		}
 
-	// Run discovery immediately on startup
-	o.runDiscovery(ctx)
+		// Without a run ahead, the scheduler wakes up when a running discovery completes
+		timer := time.NewTimer(time.Hour)
+		if next := o.scheduler.nextWake(); !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
 
-	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("Discovery worker shutting down due to context cancellation")
+			timer.Stop()
+			logger.Info("Discovery scheduler shutting down due to context cancellation")
 			return
 		case <-o.stopChan:
-			logger.Info("Discovery worker shutting down")
+			timer.Stop()
+			logger.Info("Discovery scheduler shutting down")
 			return
-		case <-ticker.C:
-			o.runDiscovery(ctx)
+		case <-timer.C:
+		case <-o.scheduler.wake:
+			timer.Stop()
 		}
 	}
 }
 
-// runDiscovery performs the job discovery process
-func (o *Orchestrator) runDiscovery(ctx context.Context) {
-	logger.Info("Starting job discovery cycle")
+// runDiscovery performs the discovery of a company and enqueues its job references
+func (o *Orchestrator) runDiscovery(ctx context.Context, companyName string) {
+	logger.Info(fmt.Sprintf("Starting job discovery for %s", companyName))
 	startTime := time.Now()
 
-	allJobReferences, err := o.discoveryService.DiscoverJobs(ctx)
+	jobReferences, err := o.discoveryService.DiscoverJobsForCompany(ctx, companyName)
+	if err == nil {
+		o.detectExpiredJobs(ctx, companyName, jobReferences)
+	}
+	o.scheduler.finish(companyName, time.Now())
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	o.metricsMu.Lock()
+	defer o.metricsMu.Unlock()
+
+	o.metrics.DiscoveryCycles++
+	o.metrics.LastDiscoveryTime = time.Now()
+
+	if errors.Is(err, httpclient.ErrNotModified) {
+		delete(o.metrics.LastDiscoveryErrors, companyName)
+		logger.Info(fmt.Sprintf("Discovery of %s completed in %v - listing not modified", companyName, time.Since(startTime)))
+		return
+	}
 	if err != nil {
-		logger.Error(fmt.Sprintf("Error during job discovery: %v", err))
+		// A company failing does not affect the discovery of the others
+		if o.metrics.LastDiscoveryErrors == nil {
+			o.metrics.LastDiscoveryErrors = make(map[string]string)
+		}
+		o.metrics.LastDiscoveryErrors[companyName] = err.Error()
+		o.metrics.DiscoveryFailures++
+		logger.Error(fmt.Sprintf("Error during job discovery for %s: %v", companyName, err))
 		return
 	}
+	delete(o.metrics.LastDiscoveryErrors, companyName)
 
 	totalJobs := 0
-	for companyName, jobReferences := range allJobReferences {
-		logger.Info(fmt.Sprintf("Discovered %d job references for %s", len(jobReferences), companyName))
+	unchangedJobs := 0
+	for _, jobRef := range jobReferences {
+		// The source reports no change since the previous discovery
+		if jobRef.Unchanged {
+			unchangedJobs++
+			continue
+		}
 
-		for _, jobRef := range jobReferences {
-			o.queue.Enqueue(jobRef)
-			totalJobs++
+		if err := o.queue.Enqueue(ctx, jobRef); err != nil {
+			logger.Error(fmt.Sprintf("Failed to enqueue job reference %s: %v", jobRef.ExternalID, err))
+			continue
 		}
+		totalJobs++
 	}
-
-	// Update discovery metrics
-	o.metrics.DiscoveryCycles++
-	o.metrics.LastDiscoveryTime = time.Now()
 	o.metrics.TotalJobsDiscovered += int64(totalJobs)
 
-	duration := time.Since(startTime)
-	logger.Info(fmt.Sprintf("Discovery cycle completed in %v - added %d job references to queue", duration, totalJobs))
+	logger.Info(fmt.Sprintf("Discovery of %s completed in %v - discovered %d job references, added %d to queue, skipped %d unchanged",
+		companyName, time.Since(startTime), len(jobReferences), totalJobs, unchangedJobs))
+}
+
+// detectExpiredJobs expires the jobs of a company missing from its discovery. A listing truncated by
+// max_pages does not tell which postings closed, so it is not used.
+func (o *Orchestrator) detectExpiredJobs(ctx context.Context, companyName string, jobReferences []*models.JobReference) {
+	if o.discoveryService.IsTruncated(companyName) {
+		logger.Warn(fmt.Sprintf("Job listing of %s is truncated, skipping expiry detection", companyName))
+		return
+	}
+
+	expired, err := o.expiryService.ReconcileDiscovery(ctx, companyName, jobReferences)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error detecting expired jobs of %s: %v", companyName, err))
+	}
+	if expired == 0 {
+		return
+	}
+
+	o.metricsMu.Lock()
+	o.metrics.JobsExpired += expired
+	o.metricsMu.Unlock()
+}
+
+// runEnrichmentPool runs the enrichment workers until the orchestrator is stopped
+func (o *Orchestrator) runEnrichmentPool(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-o.stopChan:
+			logger.Info("Enrichment pool shutting down")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	o.pool.run(ctx)
 }
 
-// runEnrichmentWorker runs the job enrichment process continuously
-func (o *Orchestrator) runEnrichmentWorker(ctx context.Context) {
-	logger.Info("Starting enrichment worker")
+// runDeadLetterRetries enqueues the dead letters due for a retry until the orchestrator is stopped
+func (o *Orchestrator) runDeadLetterRetries(ctx context.Context) {
+	ticker := time.NewTicker(o.config.DeadLetterPollInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("Enrichment worker shutting down due to context cancellation")
 			return
 		case <-o.stopChan:
-			logger.Info("Enrichment worker shutting down")
+			logger.Info("Dead-letter retries shutting down")
 			return
-		default:
-			o.processNextJob(ctx)
+		case <-ticker.C:
 		}
-	}
-}
 
-// processNextJob processes the next job reference from the queue
-func (o *Orchestrator) processNextJob(ctx context.Context) {
-	if o.queue.IsEmpty() {
-		time.Sleep(o.config.ProcessingDelay)
-		return
-	}
+		jobRefs, err := o.deadLetterService.ClaimDue(ctx, o.config.DeadLetterBatchSize)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error claiming dead letters due for a retry: %v", err))
+			continue
+		}
 
-	jobRef := o.queue.Dequeue()
-	if jobRef == nil {
-		return
+		for _, jobRef := range jobRefs {
+			if err := o.queue.Enqueue(ctx, jobRef); err != nil {
+				logger.Error(fmt.Sprintf("Failed to enqueue retry of job reference %s: %v", jobRef.ExternalID, err))
+			}
+		}
+		if len(jobRefs) > 0 {
+			logger.Info(fmt.Sprintf("Enqueued %d dead letters for a retry", len(jobRefs)))
+		}
 	}
+}
 
+// processJob processes a job reference taken from the queue
+func (o *Orchestrator) processJob(ctx context.Context, jobRef *models.JobReference) {
 	// Track processing start
 	startTime := time.Now()
 	result := o.processJobReference(ctx, jobRef)
+	o.recordOutcome(ctx, result)
 
 	// Update metrics based on result
 	o.updateMetrics(result, time.Since(startTime))
@@ -180,8 +293,8 @@ func (o *Orchestrator) processJobReference(ctx context.Context, jobRef *models.J
 		Timestamp:    time.Now(),
 	}
 
-	// Check for duplicates
-	if o.deduplicationService.IsProcessed(jobRef) {
+	// Check for duplicates, retries of failed references being processed again on purpose
+	if jobRef.Retries == 0 && o.deduplicationService.IsProcessed(jobRef) {
 		result.Status = models.ProcessingStatusDuplicate
 		logger.Debug(fmt.Sprintf("Job reference already processed: %s", jobRef.ExternalID))
 		return result
@@ -193,10 +306,31 @@ func (o *Orchestrator) processJobReference(ctx context.Context, jobRef *models.J
 	logger.Debug(fmt.Sprintf("Processing job reference: %s", jobRef.ExternalID))
 
 	// Enrich the job reference
-	jobDetails, err := o.enrichmentService.EnrichJobReference(ctx, jobRef)
+	jobDetails, commit, err := o.enrichmentService.EnrichJobReference(ctx, jobRef)
+	if errors.Is(err, httpclient.ErrNotFound) {
+		// The posting was taken down since it was discovered
+		if err := o.expiryService.ExpireJob(ctx, jobRef); err != nil {
+			result.Status = models.ProcessingStatusFailed
+			result.Error = err.Error()
+			result.ErrorClass = "persistence_error"
+			logger.Error(fmt.Sprintf("Error expiring job %s: %v", jobRef.ExternalID, err))
+			return result
+		}
+		result.Status = models.ProcessingStatusExpired
+		return result
+	}
+	if errors.Is(err, httpclient.ErrNotModified) {
+		result.Status = models.ProcessingStatusSkipped
+		logger.Debug(fmt.Sprintf("Job posting not modified since it was last enriched: %s", jobRef.ExternalID))
+		return result
+	}
 	if err != nil {
 		result.Status = models.ProcessingStatusFailed
 		result.Error = err.Error()
+		result.ErrorClass = httpclient.ErrorType(err)
+		if result.ErrorClass == "" {
+			result.ErrorClass = "enrichment_error"
+		}
 		logger.Error(fmt.Sprintf("Error enriching job reference %s: %v", jobRef.ExternalID, err))
 		return result
 	}
@@ -208,10 +342,14 @@ func (o *Orchestrator) processJobReference(ctx context.Context, jobRef *models.J
 	if err != nil {
 		result.Status = models.ProcessingStatusFailed
 		result.Error = err.Error()
+		result.ErrorClass = "persistence_error"
 		logger.Error(fmt.Sprintf("Error persisting job details %s: %v", jobDetails.ExternalID, err))
 		return result
 	}
 
+	// The posting is only requested conditionally once its job was saved, so that a retry is not skipped
+	commit()
+
 	result.Status = models.ProcessingStatusSuccess
 	result.JobDetails = jobDetails
 	logger.Debug(fmt.Sprintf("Successfully persisted job: %s", jobDetails.Title))
@@ -219,8 +357,32 @@ func (o *Orchestrator) processJobReference(ctx context.Context, jobRef *models.J
 	return result
 }
 
+// recordOutcome stores a failed job reference in the dead-letter store for it to be retried, and removes
+// the dead letter of a reference processed since. Failures caused by the shutdown are left to the queue,
+// which delivers the reference again.
+func (o *Orchestrator) recordOutcome(ctx context.Context, result models.ProcessingResult) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	jobRef := result.JobReference
+	switch result.Status {
+	case models.ProcessingStatusFailed:
+		if err := o.deadLetterService.Record(ctx, jobRef, result.ErrorClass, result.Error); err != nil {
+			logger.Error(fmt.Sprintf("Error recording dead letter of job reference %s: %v", jobRef.ExternalID, err))
+		}
+	case models.ProcessingStatusSuccess, models.ProcessingStatusSkipped, models.ProcessingStatusExpired:
+		if err := o.deadLetterService.Resolve(ctx, jobRef); err != nil {
+			logger.Error(fmt.Sprintf("Error resolving dead letter of job reference %s: %v", jobRef.ExternalID, err))
+		}
+	}
+}
+
 // updateMetrics updates the processing metrics based on the result
 func (o *Orchestrator) updateMetrics(result models.ProcessingResult, duration time.Duration) {
+	o.metricsMu.Lock()
+	defer o.metricsMu.Unlock()
+
 	result.ProcessingTime = duration
 	o.metrics.JobsProcessed++
 
@@ -231,6 +393,8 @@ func (o *Orchestrator) updateMetrics(result models.ProcessingResult, duration ti
 		o.metrics.JobsFailed++
 	case models.ProcessingStatusDuplicate:
 		o.metrics.JobsDuplicate++
+	case models.ProcessingStatusExpired:
+		o.metrics.JobsExpired++
 	}
 
 	// Update timing metrics
@@ -256,12 +420,22 @@ func (o *Orchestrator) logProcessingResult(result models.ProcessingResult) {
 	case models.ProcessingStatusDuplicate:
 		logger.Debug(fmt.Sprintf("Skipped duplicate job %s",
 			result.JobReference.ExternalID))
+	case models.ProcessingStatusSkipped:
+		logger.Debug(fmt.Sprintf("Skipped unmodified job %s",
+			result.JobReference.ExternalID))
+	case models.ProcessingStatusExpired:
+		logger.Info(fmt.Sprintf("Expired job %s, its posting was not found",
+			result.JobReference.ExternalID))
 	}
 }
 
 // GetQueueSize returns the current size of the job reference queue
 func (o *Orchestrator) GetQueueSize() int {
-	return o.queue.Size()
+	size, err := o.queue.Size(context.Background())
+	if err != nil {
+		logger.Error("Failed to get the job reference queue size", "error", err)
+	}
+	return size
 }
 
 // GetStatus returns the current status of the orchestrator using structured models
@@ -274,11 +448,24 @@ func (o *Orchestrator) GetStatus() models.PipelineStatus {
 		EnrichmentCompanies: len(o.enrichmentService.GetSupportedCompanies()),
 		StartTime:           o.startTime,
 		Uptime:              uptime.String(),
-		Metrics:             o.metrics,
+		EnrichmentWorkers:   o.pool.size,
+		BusyWorkers:         o.pool.busyWorkers(),
+		Schedules:           o.scheduler.status(),
+		Metrics:             o.GetMetrics(),
 	}
 }
 
 // GetMetrics returns the current processing metrics
 func (o *Orchestrator) GetMetrics() models.ProcessingMetrics {
-	return o.metrics
+	o.metricsMu.Lock()
+	defer o.metricsMu.Unlock()
+
+	metrics := o.metrics
+	if o.metrics.LastDiscoveryErrors != nil {
+		metrics.LastDiscoveryErrors = make(map[string]string, len(o.metrics.LastDiscoveryErrors))
+		for company, err := range o.metrics.LastDiscoveryErrors {
+			metrics.LastDiscoveryErrors[company] = err
+		}
+	}
+	return metrics
 }
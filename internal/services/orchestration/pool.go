@@ -0,0 +1,411 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/fetcher"
+	"github.com/gkettani/bobber-the-swe/internal/logger"
+	"github.com/gkettani/bobber-the-swe/internal/metrics"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/queue"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxParkedJobs bounds the job references held back by the concurrency caps, past which no more
+// references are taken from the queue until the caps free up. Their claims are extended while parked.
+const maxParkedJobs = 1000
+
+// queueRetryDelay is how long the pool waits before taking references again after the queue failed
+const queueRetryDelay = 5 * time.Second
+
+// enrichmentPool processes job references with a fixed number of workers, never enriching more than
+// the configured number of postings of a company or of a host at once so that a backlog of postings
+// from one ATS is not sent to it all at once
+type enrichmentPool struct {
+	size         int
+	companyLimit int
+	hostLimit    int
+	queue        queue.Queue
+	process      func(ctx context.Context, jobRef *models.JobReference)
+
+	jobs     chan *queue.Delivery
+	released chan struct{} // signaled when a job completes, for the parked jobs to be dispatched
+
+	mu         sync.Mutex
+	perCompany map[string]int
+	perHost    map[string]int
+	dispatched int // job references sent to the workers and not yet released
+	busy       int
+
+	metrics *enrichmentPoolMetrics
+}
+
+type enrichmentPoolMetrics struct {
+	workers     prometheus.Gauge
+	busyWorkers prometheus.Gauge
+	utilization prometheus.Gauge
+	parkedJobs  prometheus.Gauge
+	capped      *prometheus.CounterVec
+}
+
+func newEnrichmentPool(config Config, jobQueue queue.Queue, process func(ctx context.Context, jobRef *models.JobReference)) *enrichmentPool {
+	metricsManager := metrics.GetManager()
+
+	return &enrichmentPool{
+		size:         max(config.EnrichmentWorkers, 1),
+		companyLimit: config.CompanyConcurrency,
+		hostLimit:    config.HostConcurrency,
+		queue:        jobQueue,
+		process:      process,
+		jobs:         make(chan *queue.Delivery),
+		released:     make(chan struct{}, 1),
+		perCompany:   make(map[string]int),
+		perHost:      make(map[string]int),
+		metrics: &enrichmentPoolMetrics{
+			workers: metricsManager.CreateGauge(
+				"enrichment_pool_workers",
+				"Number of enrichment workers",
+			),
+			busyWorkers: metricsManager.CreateGauge(
+				"enrichment_pool_busy_workers",
+				"Number of enrichment workers processing a job reference",
+			),
+			utilization: metricsManager.CreateGauge(
+				"enrichment_pool_utilization",
+				"Ratio of busy enrichment workers",
+			),
+			parkedJobs: metricsManager.CreateGauge(
+				"enrichment_pool_parked_jobs",
+				"Number of job references waiting for their company or host concurrency cap",
+			),
+			capped: metricsManager.CreateCounterVec(
+				"enrichment_pool_capped_total",
+				"Total number of job references held back by a concurrency cap",
+				[]string{"limit"},
+			),
+		},
+	}
+}
+
+// run starts the workers and dispatches the job references of the queue to them until ctx is done
+func (p *enrichmentPool) run(ctx context.Context) {
+	p.metrics.workers.Set(float64(p.size))
+	logger.Info("Starting enrichment pool", "workers", p.size, "company_concurrency", p.companyLimit, "host_concurrency", p.hostLimit)
+
+	for i := 0; i < p.size; i++ {
+		go p.work(ctx)
+	}
+
+	requests := make(chan struct{}, 1)
+	incoming := make(chan *queue.Delivery)
+	go p.feed(ctx, requests, incoming)
+
+	var parked []*parkedJob
+	requested := false
+	for {
+		parked = p.dispatchParked(ctx, parked)
+		p.metrics.parkedJobs.Set(float64(len(parked)))
+
+		// A reference is only claimed for a free worker, while there is room to park it should its caps be
+		// exhausted, so that claims do not time out waiting for a worker
+		if !requested && p.freeWorkers() > 0 && len(parked) < maxParkedJobs {
+			requests <- struct{}{}
+			requested = true
+		}
+
+		var renewal <-chan time.Time
+		if renewAt, ok := nextRenewal(parked); ok {
+			renewal = time.After(time.Until(renewAt))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-incoming:
+			requested = false
+
+			// The worker it was claimed for may have been given a parked job in the meantime
+			if p.freeWorkers() == 0 {
+				parked = append(parked, &parkedJob{delivery: delivery, renewAt: renewalTime(delivery)})
+				continue
+			}
+			if limit := p.acquire(delivery.JobRef); limit != "" {
+				p.metrics.capped.WithLabelValues(limit).Inc()
+				parked = append(parked, &parkedJob{delivery: delivery, renewAt: renewalTime(delivery)})
+				continue
+			}
+			p.send(ctx, delivery)
+		case <-renewal:
+			parked = p.renewParked(ctx, parked)
+		case <-p.released:
+		}
+	}
+}
+
+// parkedJob is a job reference held back by a concurrency cap, whose claim is extended until it is dispatched
+type parkedJob struct {
+	delivery *queue.Delivery
+	renewAt  time.Time
+}
+
+// renewalTime is halfway to the deadline of a claim, leaving time for its extension to be retried
+func renewalTime(delivery *queue.Delivery) time.Time {
+	return time.Now().Add(time.Until(delivery.Deadline) / 2)
+}
+
+func nextRenewal(parked []*parkedJob) (time.Time, bool) {
+	var renewAt time.Time
+	for _, job := range parked {
+		if renewAt.IsZero() || job.renewAt.Before(renewAt) {
+			renewAt = job.renewAt
+		}
+	}
+	return renewAt, !renewAt.IsZero()
+}
+
+// feed claims a job reference from the queue whenever the dispatcher asks for one
+func (p *enrichmentPool) feed(ctx context.Context, requests <-chan struct{}, incoming chan<- *queue.Delivery) {
+	for {
+		select {
+		case <-requests:
+		case <-ctx.Done():
+			return
+		}
+
+		delivery, ok := p.next(ctx)
+		if !ok {
+			return
+		}
+
+		select {
+		case incoming <- delivery:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// next claims a job reference from the queue, retrying when the queue fails until ctx is done
+func (p *enrichmentPool) next(ctx context.Context) (*queue.Delivery, bool) {
+	for {
+		delivery, err := p.queue.Next(ctx)
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		if err == nil {
+			return delivery, true
+		}
+
+		logger.Error(fmt.Sprintf("Failed to take a job reference from the queue, retrying in %v: %v", queueRetryDelay, err))
+		select {
+		case <-time.After(queueRetryDelay):
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// dispatchParked sends the parked jobs whose caps allow it to the free workers, returning the others
+func (p *enrichmentPool) dispatchParked(ctx context.Context, parked []*parkedJob) []*parkedJob {
+	remaining := parked[:0]
+	for _, job := range parked {
+		if ctx.Err() == nil && p.freeWorkers() > 0 && p.acquire(job.delivery.JobRef) == "" {
+			p.send(ctx, job.delivery)
+			continue
+		}
+		remaining = append(remaining, job)
+	}
+	return remaining
+}
+
+// renewParked extends the claims of the parked jobs due for it. A job whose claim timed out is dropped,
+// the queue delivering it again.
+func (p *enrichmentPool) renewParked(ctx context.Context, parked []*parkedJob) []*parkedJob {
+	remaining := parked[:0]
+	for _, job := range parked {
+		if ctx.Err() != nil || job.renewAt.After(time.Now()) {
+			remaining = append(remaining, job)
+			continue
+		}
+
+		err := p.queue.Extend(ctx, job.delivery)
+		switch {
+		case errors.Is(err, queue.ErrClaimLost):
+			logger.Warn(fmt.Sprintf("Claim of parked job reference %s timed out, leaving it to the queue", job.delivery.JobRef.ExternalID))
+			continue
+		case err != nil:
+			logger.Error(fmt.Sprintf("Failed to extend the claim of job reference %s, retrying in %v: %v", job.delivery.JobRef.ExternalID, queueRetryDelay, err))
+			job.renewAt = time.Now().Add(queueRetryDelay)
+		default:
+			job.renewAt = renewalTime(job.delivery)
+		}
+		remaining = append(remaining, job)
+	}
+	return remaining
+}
+
+// send hands a job reference to a worker. Past the shutdown, it is left unacknowledged for the queue
+// to deliver it again.
+func (p *enrichmentPool) send(ctx context.Context, delivery *queue.Delivery) {
+	select {
+	case p.jobs <- delivery:
+	case <-ctx.Done():
+		p.release(delivery.JobRef)
+	}
+}
+
+func (p *enrichmentPool) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-p.jobs:
+			p.setBusy(1)
+			claimed := p.processClaimed(ctx, delivery)
+			p.setBusy(-1)
+
+			// A job interrupted by the shutdown is delivered again after the visibility timeout, and one
+			// whose claim was lost already was
+			if ctx.Err() == nil && claimed {
+				if err := p.queue.Ack(ctx, delivery); err != nil {
+					logger.Error(fmt.Sprintf("Failed to acknowledge job reference %s: %v", delivery.JobRef.ExternalID, err))
+				}
+			}
+			p.release(delivery.JobRef)
+		}
+	}
+}
+
+// processClaimed processes a job reference while extending its claim, so that a long enrichment is not
+// delivered to another worker meanwhile. The processing is cancelled when the claim is lost anyway,
+// returning false.
+func (p *enrichmentPool) processClaimed(ctx context.Context, delivery *queue.Delivery) bool {
+	processCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lost atomic.Bool
+	renewing := make(chan struct{})
+	go func() {
+		defer close(renewing)
+		if p.keepClaim(processCtx, delivery) {
+			lost.Store(true)
+			cancel()
+		}
+	}()
+
+	p.process(processCtx, delivery.JobRef)
+	cancel()
+	<-renewing
+	return !lost.Load()
+}
+
+// keepClaim extends the claim of a job reference until ctx is done, returning whether it was lost
+func (p *enrichmentPool) keepClaim(ctx context.Context, delivery *queue.Delivery) bool {
+	renewAt := renewalTime(delivery)
+	for {
+		timer := time.NewTimer(time.Until(renewAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+
+		err := p.queue.Extend(ctx, delivery)
+		switch {
+		case errors.Is(err, queue.ErrClaimLost):
+			logger.Warn(fmt.Sprintf("Claim of job reference %s timed out while processing it, cancelling it", delivery.JobRef.ExternalID))
+			return true
+		case err != nil:
+			if ctx.Err() != nil {
+				return false
+			}
+			logger.Error(fmt.Sprintf("Failed to extend the claim of job reference %s, retrying in %v: %v", delivery.JobRef.ExternalID, queueRetryDelay, err))
+			renewAt = time.Now().Add(queueRetryDelay)
+		default:
+			renewAt = renewalTime(delivery)
+		}
+	}
+}
+
+// acquire takes a worker and a slot of the company and host caps of a job if both caps have one left,
+// returning the name of the exhausted cap otherwise. The caller checks that a worker is free.
+func (p *enrichmentPool) acquire(jobRef *models.JobReference) string {
+	company, host := jobRef.CompanyName, jobHost(jobRef)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.companyLimit > 0 && p.perCompany[company] >= p.companyLimit {
+		return "company"
+	}
+	if p.hostLimit > 0 && host != "" && p.perHost[host] >= p.hostLimit {
+		return "host"
+	}
+
+	p.dispatched++
+	p.perCompany[company]++
+	if host != "" {
+		p.perHost[host]++
+	}
+	return ""
+}
+
+func (p *enrichmentPool) release(jobRef *models.JobReference) {
+	company, host := jobRef.CompanyName, jobHost(jobRef)
+
+	p.mu.Lock()
+	p.dispatched--
+	if p.perCompany[company]--; p.perCompany[company] <= 0 {
+		delete(p.perCompany, company)
+	}
+	if host != "" {
+		if p.perHost[host]--; p.perHost[host] <= 0 {
+			delete(p.perHost, host)
+		}
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.released <- struct{}{}:
+	default:
+	}
+}
+
+func (p *enrichmentPool) setBusy(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.busy += delta
+	p.metrics.busyWorkers.Set(float64(p.busy))
+	p.metrics.utilization.Set(float64(p.busy) / float64(p.size))
+}
+
+// freeWorkers returns the number of workers no job reference was dispatched to
+func (p *enrichmentPool) freeWorkers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size - p.dispatched
+}
+
+// busyWorkers returns the number of workers processing a job reference
+func (p *enrichmentPool) busyWorkers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.busy
+}
+
+// jobHost returns the host a job reference is enriched from, its ATS API when it has one
+func jobHost(jobRef *models.JobReference) string {
+	parsed, err := url.Parse(fetcher.EnrichmentURL(jobRef))
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
@@ -0,0 +1,265 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/queue"
+)
+
+// processRecorder counts the job references processed by a pool, and the most of them processed at
+// once per company and per host
+type processRecorder struct {
+	mu         sync.Mutex
+	processed  map[string]int
+	running    map[string]int
+	maxRunning map[string]int
+}
+
+func newProcessRecorder() *processRecorder {
+	return &processRecorder{
+		processed:  make(map[string]int),
+		running:    make(map[string]int),
+		maxRunning: make(map[string]int),
+	}
+}
+
+// process returns a process function taking the given duration per job reference
+func (r *processRecorder) process(duration time.Duration) func(ctx context.Context, jobRef *models.JobReference) {
+	return func(ctx context.Context, jobRef *models.JobReference) {
+		keys := []string{"company " + jobRef.CompanyName, "host " + jobHost(jobRef)}
+
+		r.mu.Lock()
+		for _, key := range keys {
+			r.running[key]++
+			r.maxRunning[key] = max(r.maxRunning[key], r.running[key])
+		}
+		r.mu.Unlock()
+
+		time.Sleep(duration)
+
+		r.mu.Lock()
+		for _, key := range keys {
+			r.running[key]--
+		}
+		r.processed[jobRef.ExternalID]++
+		r.mu.Unlock()
+	}
+}
+
+func (r *processRecorder) count(externalID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.processed[externalID]
+}
+
+func (r *processRecorder) maxConcurrency(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.maxRunning[key]
+}
+
+// startPool runs a pool on the queue until the test ends
+func startPool(t *testing.T, config Config, jobQueue queue.Queue, process func(ctx context.Context, jobRef *models.JobReference)) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go newEnrichmentPool(config, jobQueue, process).run(ctx)
+}
+
+// waitForEmptyQueue waits until every reference of the queue was acknowledged
+func waitForEmptyQueue(t *testing.T, jobQueue queue.Queue, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		size, err := jobQueue.Size(context.Background())
+		if err != nil {
+			t.Fatalf("Size() error = %v", err)
+		}
+		if size == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Queue still holds %d references after %v", size, timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func enqueueJobs(t *testing.T, jobQueue queue.Queue, jobRefs ...*models.JobReference) {
+	t.Helper()
+
+	for _, jobRef := range jobRefs {
+		if err := jobQueue.Enqueue(context.Background(), jobRef); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+}
+
+func TestEnrichmentPool_ParkedClaimsExtended(t *testing.T) {
+	const visibilityTimeout = 200 * time.Millisecond
+	jobQueue := queue.NewInMemoryQueue(visibilityTimeout)
+
+	var ids []string
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("job-%d", i)
+		ids = append(ids, id)
+		enqueueJobs(t, jobQueue, &models.JobReference{ExternalID: id, CompanyName: "acme", URL: "https://acme.test/jobs/" + id})
+	}
+
+	// Each job is processed within the visibility timeout, but the company enriching one posting at a
+	// time, the last ones stay parked for longer
+	recorder := newProcessRecorder()
+	startPool(t, Config{EnrichmentWorkers: 2, CompanyConcurrency: 1}, jobQueue, recorder.process(visibilityTimeout/2))
+
+	waitForEmptyQueue(t, jobQueue, 3*time.Second)
+
+	// A claim timing out while parked would deliver the job again to the free worker
+	time.Sleep(visibilityTimeout)
+	for _, id := range ids {
+		if count := recorder.count(id); count != 1 {
+			t.Errorf("Job %s processed %d times, want once", id, count)
+		}
+	}
+}
+
+func TestEnrichmentPool_ProcessedClaimsExtended(t *testing.T) {
+	const visibilityTimeout = 100 * time.Millisecond
+	jobQueue := queue.NewInMemoryQueue(visibilityTimeout)
+	enqueueJobs(t, jobQueue, &models.JobReference{ExternalID: "job-1", CompanyName: "acme", URL: "https://acme.test/jobs/1"})
+
+	// The enrichment outlasts several visibility timeouts, a second worker waiting for the reference to
+	// be delivered again
+	recorder := newProcessRecorder()
+	startPool(t, Config{EnrichmentWorkers: 2}, jobQueue, recorder.process(4*visibilityTimeout))
+
+	waitForEmptyQueue(t, jobQueue, 3*time.Second)
+	time.Sleep(visibilityTimeout)
+	if count := recorder.count("job-1"); count != 1 {
+		t.Errorf("Job job-1 processed %d times, want once", count)
+	}
+}
+
+// lostClaimQueue loses every claim it is asked to extend, counting the acknowledgements
+type lostClaimQueue struct {
+	*queue.InMemoryQueue
+	acks atomic.Int32
+}
+
+func (q *lostClaimQueue) Extend(ctx context.Context, delivery *queue.Delivery) error {
+	return queue.ErrClaimLost
+}
+
+func (q *lostClaimQueue) Ack(ctx context.Context, delivery *queue.Delivery) error {
+	q.acks.Add(1)
+	return q.InMemoryQueue.Ack(ctx, delivery)
+}
+
+func TestEnrichmentPool_LostClaimCancelsProcessing(t *testing.T) {
+	const visibilityTimeout = 100 * time.Millisecond
+	jobQueue := &lostClaimQueue{InMemoryQueue: queue.NewInMemoryQueue(visibilityTimeout)}
+	enqueueJobs(t, jobQueue, &models.JobReference{ExternalID: "job-1", CompanyName: "acme", URL: "https://acme.test/jobs/1"})
+
+	cancelled := make(chan struct{}, 1)
+	startPool(t, Config{EnrichmentWorkers: 1}, jobQueue, func(ctx context.Context, jobRef *models.JobReference) {
+		select {
+		case <-ctx.Done():
+			cancelled <- struct{}{}
+		case <-time.After(10 * visibilityTimeout):
+		}
+	})
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * visibilityTimeout):
+		t.Fatal("Expected the processing to be cancelled once its claim was lost")
+	}
+
+	// The reference is left to the queue, which delivers it again
+	time.Sleep(visibilityTimeout / 2)
+	if acks := jobQueue.acks.Load(); acks != 0 {
+		t.Errorf("Expected the lost claim not to be acknowledged, got %d acks", acks)
+	}
+}
+
+func TestEnrichmentPool_ConcurrencyCaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		jobRefs func(i int) *models.JobReference
+		wantMax map[string]int
+	}{
+		{
+			name:   "company cap",
+			config: Config{EnrichmentWorkers: 4, CompanyConcurrency: 2},
+			jobRefs: func(i int) *models.JobReference {
+				return &models.JobReference{CompanyName: "acme", URL: fmt.Sprintf("https://jobs%d.acme.test/jobs/%d", i, i)}
+			},
+			wantMax: map[string]int{"company acme": 2},
+		},
+		{
+			// Postings of companies hosted on one ATS are enriched from its API, whatever their career site
+			name:   "host cap on the API host",
+			config: Config{EnrichmentWorkers: 4, HostConcurrency: 1},
+			jobRefs: func(i int) *models.JobReference {
+				return &models.JobReference{
+					CompanyName: fmt.Sprintf("company-%d", i),
+					URL:         fmt.Sprintf("https://careers.company-%d.test/jobs/%d", i, i),
+					APIURL:      fmt.Sprintf("https://api.lever.test/v0/postings/company-%d/%d", i, i),
+				}
+			},
+			wantMax: map[string]int{"host api.lever.test": 1},
+		},
+		{
+			// Lever postings found on career pages are enriched from the Lever API, not their hosted page
+			name:   "host cap on the API of hosted postings",
+			config: Config{EnrichmentWorkers: 4, HostConcurrency: 1},
+			jobRefs: func(i int) *models.JobReference {
+				return &models.JobReference{
+					CompanyName: fmt.Sprintf("company-%d", i),
+					Source:      "html",
+					URL:         fmt.Sprintf("https://jobs.lever.co/company-%d/%d", i, i),
+				}
+			},
+			wantMax: map[string]int{"host api.lever.co": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobQueue := queue.NewInMemoryQueue(time.Minute)
+
+			var ids []string
+			for i := 1; i <= 8; i++ {
+				jobRef := tt.jobRefs(i)
+				jobRef.ExternalID = fmt.Sprintf("job-%d", i)
+				ids = append(ids, jobRef.ExternalID)
+				enqueueJobs(t, jobQueue, jobRef)
+			}
+
+			recorder := newProcessRecorder()
+			startPool(t, tt.config, jobQueue, recorder.process(20*time.Millisecond))
+
+			// The jobs held back by a cap are parked, and dispatched as the cap frees up
+			waitForEmptyQueue(t, jobQueue, 3*time.Second)
+			for _, id := range ids {
+				if count := recorder.count(id); count != 1 {
+					t.Errorf("Job %s processed %d times, want once", id, count)
+				}
+			}
+
+			for key, want := range tt.wantMax {
+				if got := recorder.maxConcurrency(key); got != want {
+					t.Errorf("Most jobs of %s processed at once = %d, want %d", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package orchestration
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/schedule"
+	"github.com/gkettani/bobber-the-swe/internal/services"
+)
+
+// scheduledCompany is the discovery schedule of a company and the state of its runs
+type scheduledCompany struct {
+	name     string
+	schedule schedule.Schedule
+	nextRun  time.Time // zero when the schedule has no run left
+	lastRun  time.Time
+	running  bool
+}
+
+// scheduler tracks when the discovery of each company is due. Every company is discovered on startup,
+// then whenever its schedule is due after the previous discovery completed, so that a company is never
+// discovered twice at once.
+type scheduler struct {
+	mu        sync.Mutex
+	companies map[string]*scheduledCompany
+
+	// Signaled when a discovery completes, for the next run to be rescheduled
+	wake chan struct{}
+}
+
+func newScheduler(discoveryService services.JobDiscoveryService, defaultSchedule schedule.Schedule, now time.Time) *scheduler {
+	s := &scheduler{
+		companies: make(map[string]*scheduledCompany),
+		wake:      make(chan struct{}, 1),
+	}
+
+	for _, name := range discoveryService.GetRegisteredCompanies() {
+		companySchedule := discoveryService.GetSchedule(name)
+		if companySchedule == nil {
+			companySchedule = defaultSchedule
+		}
+		s.companies[name] = &scheduledCompany{
+			name:     name,
+			schedule: companySchedule,
+			nextRun:  now,
+		}
+	}
+
+	return s
+}
+
+// due returns the companies whose discovery is due, marking them as running
+func (s *scheduler) due(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []string
+	for _, company := range s.companies {
+		if company.running || company.nextRun.IsZero() || company.nextRun.After(now) {
+			continue
+		}
+		company.running = true
+		company.lastRun = now
+		due = append(due, company.name)
+	}
+	return due
+}
+
+// nextWake returns the earliest next run of the companies not being discovered, zero when there is none
+func (s *scheduler) nextWake() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, company := range s.companies {
+		if company.running || company.nextRun.IsZero() {
+			continue
+		}
+		if next.IsZero() || company.nextRun.Before(next) {
+			next = company.nextRun
+		}
+	}
+	return next
+}
+
+// finish schedules the next discovery of a company after the one that completed
+func (s *scheduler) finish(name string, completed time.Time) {
+	s.mu.Lock()
+	if company, exists := s.companies[name]; exists {
+		company.running = false
+		company.nextRun = company.schedule.Next(completed)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// status returns the schedules of the companies, ordered by next run
+func (s *scheduler) status() []models.CompanySchedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules := make([]models.CompanySchedule, 0, len(s.companies))
+	for _, company := range s.companies {
+		schedules = append(schedules, models.CompanySchedule{
+			Company:  company.name,
+			Schedule: company.schedule.String(),
+			NextRun:  company.nextRun,
+			LastRun:  company.lastRun,
+			Running:  company.running,
+		})
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].NextRun.Equal(schedules[j].NextRun) {
+			return schedules[i].NextRun.Before(schedules[j].NextRun)
+		}
+		return schedules[i].Company < schedules[j].Company
+	})
+	return schedules
+}
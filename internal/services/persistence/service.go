@@ -29,6 +29,9 @@ func (s *service) SaveJobDetails(ctx context.Context, jobDetails *models.JobDeta
 	if !jobDetails.IsValid() {
 		return fmt.Errorf("invalid job details: missing required fields")
 	}
+	if jobDetails.Hash == "" {
+		jobDetails.Hash = jobDetails.ContentHash()
+	}
 
 	// Use upsert to handle duplicates gracefully
 	return s.repository.Upsert(ctx, jobDetails)
@@ -45,6 +48,9 @@ func (s *service) SaveJobDetailsBatch(ctx context.Context, jobDetailsList []*mod
 		if !jobDetails.IsValid() {
 			return fmt.Errorf("invalid job details in batch: missing required fields for job %s", jobDetails.ExternalID)
 		}
+		if jobDetails.Hash == "" {
+			jobDetails.Hash = jobDetails.ContentHash()
+		}
 	}
 
 	return s.repository.BulkInsert(ctx, jobDetailsList)
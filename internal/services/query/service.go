@@ -78,7 +78,9 @@ func (s *jobQueryService) GetJobs(ctx context.Context, filters *models.JobFilter
 // GetJobByID retrieves a specific job by ID
 func (s *jobQueryService) GetJobByID(ctx context.Context, id int64) (*models.JobDetails, error) {
 	query := `
-		SELECT id, external_id, company_name, url, title, location, description,
+		SELECT id, external_id, company_name, source, url, title, location, description,
+		       department, offices, posting_updated_at, team, employment_type, workplace_type,
+		       date_posted, valid_through, salary_min, salary_max, salary_currency, salary_unit, hiring_organization,
 		       first_seen_at, last_seen_at
 		FROM jobs 
 		WHERE id = $1
@@ -96,6 +98,31 @@ func (s *jobQueryService) GetJobByID(ctx context.Context, id int64) (*models.Job
 	return &job, nil
 }
 
+// GetJobHistory retrieves the changes of a job, most recent first
+func (s *jobQueryService) GetJobHistory(ctx context.Context, id int64) (*models.JobHistory, error) {
+	job, err := s.GetJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT job_id AS id, url, title, location, description,
+		       department, offices, posting_updated_at, team, employment_type, workplace_type,
+		       date_posted, valid_through, salary_min, salary_max, salary_currency, salary_unit, hiring_organization,
+		       recorded_at
+		FROM job_revisions
+		WHERE job_id = $1
+		ORDER BY recorded_at DESC, id DESC
+	`
+
+	var revisions []*models.JobRevision
+	if err := s.db.SelectContext(ctx, &revisions, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get job history: %w", err)
+	}
+
+	return models.NewJobHistory(job, revisions), nil
+}
+
 // SearchJobs performs optimized full-text search on jobs
 func (s *jobQueryService) SearchJobs(ctx context.Context, searchQuery string, pagination *models.Pagination) (*models.JobList, error) {
 	if searchQuery == "" {
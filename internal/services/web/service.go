@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"github.com/gkettani/bobber-the-swe/internal/handlers"
 	"github.com/gkettani/bobber-the-swe/internal/logger"
 	"github.com/gkettani/bobber-the-swe/internal/middlewares"
+	"github.com/gkettani/bobber-the-swe/internal/services"
 	"github.com/gkettani/bobber-the-swe/internal/services/orchestration"
 	"github.com/gkettani/bobber-the-swe/internal/services/query"
 )
@@ -33,6 +35,8 @@ type webService struct {
 	jobHandler     *handlers.JobHandler
 	companyHandler *handlers.CompanyHandler
 	metricsHandler *handlers.MetricsHandler
+	deadLetters    *handlers.DeadLetterHandler
+	adminToken     string
 	templates      *template.Template
 }
 
@@ -40,6 +44,9 @@ type webService struct {
 type Config struct {
 	Port int    `env:"WEB_SERVICE_PORT" envDefault:"8080"`
 	Host string `env:"WEB_SERVICE_HOST" envDefault:"localhost"`
+
+	// AdminToken is the bearer token of the admin API, which is disabled when it is empty
+	AdminToken string `env:"ADMIN_API_TOKEN"`
 }
 
 // LoadConfig loads the web service configuration
@@ -53,8 +60,9 @@ func LoadConfig() *Config {
 }
 
 // NewWebService creates a new web service
-func NewWebService(orchestrator *orchestration.Orchestrator) WebService {
+func NewWebService(orchestrator *orchestration.Orchestrator, deadLetterService services.DeadLetterService) WebService {
 	config := LoadConfig()
+	logger.RegisterSecret(config.AdminToken)
 
 	queryService := query.NewJobQueryService()
 	jobHandler := handlers.NewJobHandler(queryService)
@@ -74,6 +82,8 @@ func NewWebService(orchestrator *orchestration.Orchestrator) WebService {
 		jobHandler:     jobHandler,
 		companyHandler: companyHandler,
 		metricsHandler: metricsHandler,
+		deadLetters:    handlers.NewDeadLetterHandler(deadLetterService),
+		adminToken:     config.AdminToken,
 		templates:      templates,
 	}
 
@@ -100,6 +110,7 @@ func (ws *webService) setupRoutes() {
 	mux.HandleFunc("/api/metrics", middlewares.WrapHandler(ws.metricsHandler.GetPipelineMetrics))
 	mux.HandleFunc("/api/health", middlewares.WrapHandler(ws.metricsHandler.GetHealthStatus))
 	mux.HandleFunc("/api/dashboard", middlewares.WrapHandler(ws.metricsHandler.GetDashboardData))
+	mux.HandleFunc("/api/admin/companies/", middlewares.WrapHandler(ws.requireAdmin(ws.handleAdminCompaniesAPI)))
 
 	mux.HandleFunc("/", middlewares.WrapHandler(ws.serveHome))
 	mux.HandleFunc("/jobs", middlewares.WrapHandler(ws.serveJobsPage))
@@ -197,6 +208,12 @@ func (ws *webService) handleJobsAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 4 && parts[1] == "jobs" && parts[3] == "history" {
+		// /api/jobs/{id}/history
+		ws.jobHandler.GetJobHistory(w, r)
+		return
+	}
+
 	http.NotFound(w, r)
 }
 
@@ -214,6 +231,59 @@ func (ws *webService) handleCompaniesAPI(w http.ResponseWriter, r *http.Request)
 	http.NotFound(w, r)
 }
 
+// handleAdminCompaniesAPI routes company-related admin API requests
+func (ws *webService) handleAdminCompaniesAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 5 && parts[4] == "dead-letters" {
+		// /api/admin/companies/{key}/dead-letters
+		switch r.Method {
+		case http.MethodGet:
+			ws.deadLetters.ListDeadLetters(w, r)
+		case http.MethodDelete:
+			ws.deadLetters.PurgeDeadLetters(w, r)
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if len(parts) == 6 && parts[4] == "dead-letters" && parts[5] == "retry" {
+		// /api/admin/companies/{key}/dead-letters/retry
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ws.deadLetters.RetryDeadLetters(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// requireAdmin lets through the requests bearing the admin token, the admin API being hidden when no
+// token is configured
+func (ws *webService) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ws.adminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(ws.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
 // serveHome serves the home page
 func (ws *webService) serveHome(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -0,0 +1,182 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gkettani/bobber-the-swe/internal/handlers"
+	"github.com/gkettani/bobber-the-swe/internal/models"
+	"github.com/gkettani/bobber-the-swe/internal/services"
+)
+
+// fakeDeadLetterService records the dead-letter actions requested by the admin API
+type fakeDeadLetterService struct {
+	services.DeadLetterService
+	calls []string
+	keys  []string
+	ids   []int64
+}
+
+func (s *fakeDeadLetterService) List(ctx context.Context, companyKey string) ([]*models.DeadLetter, error) {
+	s.record("list", companyKey, 0)
+	return []*models.DeadLetter{}, nil
+}
+
+func (s *fakeDeadLetterService) Retry(ctx context.Context, companyKey string, id int64) (int64, error) {
+	s.record("retry", companyKey, id)
+	return 1, nil
+}
+
+func (s *fakeDeadLetterService) Purge(ctx context.Context, companyKey string, id int64) (int64, error) {
+	s.record("purge", companyKey, id)
+	return 1, nil
+}
+
+func (s *fakeDeadLetterService) record(call, companyKey string, id int64) {
+	s.calls = append(s.calls, call)
+	s.keys = append(s.keys, companyKey)
+	s.ids = append(s.ids, id)
+}
+
+func TestWebService_AdminDeadLetters(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminToken string
+		method     string
+		path       string
+		auth       string
+		wantStatus int
+		wantCall   string
+		wantID     int64
+	}{
+		{
+			name:       "admin API disabled without a token",
+			method:     http.MethodGet,
+			path:       "/api/admin/companies/mastercard1/dead-letters",
+			auth:       "Bearer ",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "missing token",
+			adminToken: "secret",
+			method:     http.MethodGet,
+			path:       "/api/admin/companies/mastercard1/dead-letters",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong token",
+			adminToken: "secret",
+			method:     http.MethodGet,
+			path:       "/api/admin/companies/mastercard1/dead-letters",
+			auth:       "Bearer other",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "list",
+			adminToken: "secret",
+			method:     http.MethodGet,
+			path:       "/api/admin/companies/mastercard1/dead-letters",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusOK,
+			wantCall:   "list",
+		},
+		{
+			name:       "purge all",
+			adminToken: "secret",
+			method:     http.MethodDelete,
+			path:       "/api/admin/companies/mastercard1/dead-letters",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusOK,
+			wantCall:   "purge",
+		},
+		{
+			name:       "purge one",
+			adminToken: "secret",
+			method:     http.MethodDelete,
+			path:       "/api/admin/companies/mastercard1/dead-letters?id=42",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusOK,
+			wantCall:   "purge",
+			wantID:     42,
+		},
+		{
+			name:       "retry one",
+			adminToken: "secret",
+			method:     http.MethodPost,
+			path:       "/api/admin/companies/mastercard1/dead-letters/retry?id=7",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusOK,
+			wantCall:   "retry",
+			wantID:     7,
+		},
+		{
+			name:       "invalid id",
+			adminToken: "secret",
+			method:     http.MethodPost,
+			path:       "/api/admin/companies/mastercard1/dead-letters/retry?id=-1",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "list method not allowed",
+			adminToken: "secret",
+			method:     http.MethodPost,
+			path:       "/api/admin/companies/mastercard1/dead-letters",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "retry method not allowed",
+			adminToken: "secret",
+			method:     http.MethodGet,
+			path:       "/api/admin/companies/mastercard1/dead-letters/retry",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "unknown route",
+			adminToken: "secret",
+			method:     http.MethodGet,
+			path:       "/api/admin/companies/mastercard1/jobs",
+			auth:       "Bearer secret",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadLetters := &fakeDeadLetterService{}
+			ws := &webService{
+				deadLetters: handlers.NewDeadLetterHandler(deadLetters),
+				adminToken:  tt.adminToken,
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			ws.requireAdmin(ws.handleAdminCompaniesAPI)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got: %d (%s)", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			if tt.wantCall == "" {
+				if len(deadLetters.calls) != 0 {
+					t.Errorf("Expected no dead-letter action, got: %v", deadLetters.calls)
+				}
+				return
+			}
+			if len(deadLetters.calls) != 1 || deadLetters.calls[0] != tt.wantCall {
+				t.Fatalf("Expected a %s action, got: %v", tt.wantCall, deadLetters.calls)
+			}
+			// Companies are addressed by their configuration key, which tells apart the ones sharing a name
+			if deadLetters.keys[0] != "mastercard1" || deadLetters.ids[0] != tt.wantID {
+				t.Errorf("Expected company mastercard1 and id %d, got: %s and %d", tt.wantID, deadLetters.keys[0], deadLetters.ids[0])
+			}
+		})
+	}
+}